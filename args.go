@@ -0,0 +1,162 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	argKey  = "arg"
+	restArg = "rest"
+)
+
+// positionalArgs are the items of a command tagged with `arg:"<index>"` or `arg:"rest"`.
+type positionalArgs struct {
+	// indexed are the items sorted by index.
+	indexed []configItem
+	// rest is the slice item receiving the remaining arguments, if any.
+	rest *configItem
+	// required is the number of indexed arguments without a default value.
+	required int
+}
+
+func createPositionalArgs(items []configItem, command string) (positionalArgs, error) {
+	var args positionalArgs
+	indexes := make(map[int]configItem)
+
+	for _, item := range items {
+		if item.arg == "" || item.command != command {
+			continue
+		}
+
+		if item.arg == restArg {
+			if args.rest != nil {
+				return args, fmt.Errorf("%s: %s is already the rest argument", item.name, args.rest.name)
+			}
+			if item.kind != reflect.Slice {
+				return args, fmt.Errorf("%s: the rest argument must be a slice", item.name)
+			}
+			rest := item
+			args.rest = &rest
+			continue
+		}
+
+		index, err := strconv.Atoi(item.arg)
+		if err != nil || index < 0 {
+			return args, fmt.Errorf("%s: invalid arg %q, expected an index or %q", item.name, item.arg, restArg)
+		}
+
+		if other, ok := indexes[index]; ok {
+			return args, fmt.Errorf("%s: argument %d is already %s", item.name, index, other.name)
+		}
+
+		indexes[index] = item
+	}
+
+	for i := 0; i < len(indexes); i++ {
+		item, ok := indexes[i]
+		if !ok {
+			return args, fmt.Errorf("missing positional argument %d", i)
+		}
+
+		if item.isRequiredArg() {
+			if args.required != i {
+				return args, fmt.Errorf("%s: required argument after an optional argument", item.name)
+			}
+			args.required++
+		}
+
+		args.indexed = append(args.indexed, item)
+	}
+
+	return args, nil
+}
+
+// isRequiredArg reports whether an indexed argument must be given.
+// Arguments with a default value and pointers are optional.
+func (item configItem) isRequiredArg() bool {
+	return !item.hasDefaultValue && !item.optional
+}
+
+func (args positionalArgs) empty() bool {
+	return len(args.indexed) == 0 && args.rest == nil
+}
+
+// validator returns the arity check of the command.
+func (args positionalArgs) validator() cobra.PositionalArgs {
+	switch {
+	case args.rest != nil && args.rest.isRequired:
+		return cobra.MinimumNArgs(len(args.indexed) + 1)
+	case args.rest != nil:
+		return cobra.MinimumNArgs(args.required)
+	case args.required == len(args.indexed):
+		return cobra.ExactArgs(args.required)
+	default:
+		return cobra.RangeArgs(args.required, len(args.indexed))
+	}
+}
+
+// usage returns the arguments of the usage line, e.g. "<src> <dst> [files...]".
+func (args positionalArgs) usage() string {
+	parts := make([]string, 0, len(args.indexed)+1)
+
+	for _, item := range args.indexed {
+		if item.isRequiredArg() {
+			parts = append(parts, "<"+item.argName()+">")
+		} else {
+			parts = append(parts, "["+item.argName()+"]")
+		}
+	}
+
+	if args.rest != nil {
+		if args.rest.isRequired {
+			parts = append(parts, "<"+args.rest.argName()+"...>")
+		} else {
+			parts = append(parts, "["+args.rest.argName()+"...]")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// source returns where the value of an argument item comes from.
+func (args positionalArgs) source(item configItem, values []string) Source {
+	given := len(values) > len(args.indexed)
+	for i, indexed := range args.indexed {
+		if indexed.name == item.name {
+			given = i < len(values)
+		}
+	}
+
+	switch {
+	case given:
+		return Source{Kind: SourceArg, Name: item.argName()}
+	case item.hasDefaultValue:
+		return Source{Kind: SourceDefault}
+	default:
+		return Source{}
+	}
+}
+
+// argName returns the last segment of the item name.
+func (item configItem) argName() string {
+	return item.name[strings.LastIndex(item.name, ".")+1:]
+}
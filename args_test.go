@@ -0,0 +1,113 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type argsTestSuite struct {
+	suite.Suite
+}
+
+func (s *argsTestSuite) createPositionalArgs(cfg any) (positionalArgs, error) {
+	items, err := createConfigItems(cfg)
+	s.NoError(err)
+
+	return createPositionalArgs(items, "")
+}
+
+func (s *argsTestSuite) TestCreatePositionalArgs() {
+	args, err := s.createPositionalArgs(struct {
+		Dst   string   `arg:"1"`
+		Src   string   `arg:"0"`
+		Mode  int      `arg:"2" default:"644"`
+		Files []string `arg:"rest"`
+		Force bool
+	}{})
+	s.NoError(err)
+
+	s.Equal(3, len(args.indexed))
+	s.Equal("src", args.indexed[0].name)
+	s.Equal("dst", args.indexed[1].name)
+	s.Equal("mode", args.indexed[2].name)
+	s.Equal("files", args.rest.name)
+	s.Equal(2, args.required)
+	s.Equal("<src> <dst> [mode] [files...]", args.usage())
+
+	validate := args.validator()
+	s.EqualError(validate(nil, []string{"a"}), "requires at least 2 arg(s), only received 1")
+	s.NoError(validate(nil, []string{"a", "b", "c", "d", "e"}))
+}
+
+func (s *argsTestSuite) TestPositionalArgsValidator() {
+	args, err := s.createPositionalArgs(struct {
+		Src string `arg:"0"`
+		Dst string `arg:"1"`
+	}{})
+	s.NoError(err)
+	s.Equal("<src> <dst>", args.usage())
+	s.EqualError(args.validator()(nil, []string{"a", "b", "c"}), "accepts 2 arg(s), received 3")
+
+	args, err = s.createPositionalArgs(struct {
+		Src string  `arg:"0"`
+		Dst *string `arg:"1"`
+	}{})
+	s.NoError(err)
+	s.Equal("<src> [dst]", args.usage())
+	s.EqualError(args.validator()(nil, []string{"a", "b", "c"}), "accepts between 1 and 2 arg(s), received 3")
+
+	args, err = s.createPositionalArgs(struct {
+		Files []string `arg:"rest" required:"true"`
+	}{})
+	s.NoError(err)
+	s.Equal("<files...>", args.usage())
+	s.EqualError(args.validator()(nil, []string{}), "requires at least 1 arg(s), only received 0")
+}
+
+func (s *argsTestSuite) TestCreatePositionalArgsWithInvalidTags() {
+	_, err := s.createPositionalArgs(struct {
+		Src string `arg:"first"`
+	}{})
+	s.EqualError(err, "src: invalid arg \"first\", expected an index or \"rest\"")
+
+	_, err = s.createPositionalArgs(struct {
+		Src string `arg:"0"`
+		Dst string `arg:"0"`
+	}{})
+	s.EqualError(err, "dst: argument 0 is already src")
+
+	_, err = s.createPositionalArgs(struct {
+		Src string `arg:"1"`
+	}{})
+	s.EqualError(err, "missing positional argument 0")
+
+	_, err = s.createPositionalArgs(struct {
+		Src string `arg:"0" default:"."`
+		Dst string `arg:"1"`
+	}{})
+	s.EqualError(err, "dst: required argument after an optional argument")
+
+	_, err = s.createPositionalArgs(struct {
+		Files string `arg:"rest"`
+	}{})
+	s.EqualError(err, "files: the rest argument must be a slice")
+}
+
+func TestArgsTestSuite(t *testing.T) {
+	suite.Run(t, new(argsTestSuite))
+}
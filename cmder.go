@@ -15,32 +15,97 @@
 package gocmder
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+// Cmder binds a config struct to a Cobra command and a Viper instance.
 type Cmder struct {
 	cfg       any
+	items     []configItem
 	cobra     *cobra.Command
+	commands  map[string]*cobra.Command
+	handlers  map[string]commandHandler
+	args      map[string]positionalArgs
 	viper     *viper.Viper
 	longDesc  string
 	shortDesc string
 	version   string
 	envPrefix string
+	exitCodes []exitCode
+	parsed    bool
+	keyPrefix string
+	namespace *string
+	parent    *Cmder
+	children  []*Cmder
+
+	configName  string
+	configPaths []string
+	configFiles []string
+	fs          afero.Fs
+
+	explainConfig bool
+	printConfig   bool
+	configInit    bool
+	configSchema  bool
+	configMode    ConfigMode
+	fileSources   map[string]Source
+	aliasSources  map[string]Source
+	watch         *watcher
+
+	// mu guards the Viper instance and the sources of the root, replaced by the reloads of WithWatch.
+	mu sync.RWMutex
 }
 
-type OnFinalizeFunc func(cfg any)
+// RunFunc is called with the populated config once the flags, environment variables
+// and config file have been resolved. The returned error is propagated by Execute.
+type RunFunc[T any] func(ctx context.Context, cfg *T) error
+
+type commandHandler struct {
+	cfgType reflect.Type
+	run     func(ctx context.Context, cfg any) error
+}
+
+func newCommandHandler[T any](run RunFunc[T]) commandHandler {
+	return commandHandler{
+		cfgType: reflect.TypeOf((*T)(nil)),
+		run: func(ctx context.Context, cfg any) error {
+			return run(ctx, cfg.(*T))
+		},
+	}
+}
+
+// New creates a new Cmder instance for the config struct T. It takes a callback function
+// run by the root command and a variadic list of options. The callback can be nil when
+// the root command only groups the subcommands declared with the `cmd` tag.
+func New[T any](run RunFunc[T], opts ...CmderOption) (*Cmder, error) {
+	cfg := new(T)
+
+	if kind := reflect.TypeOf(cfg).Elem().Kind(); kind != reflect.Struct {
+		return nil, fmt.Errorf("unsupported config type %s, expected a struct", kind)
+	}
 
-// NewCmder creates a new Cmder instance. It takes a config struct, a callback function
-// when the command is finalized and a variadic list of options.
-func NewCmder(cfg any, onFinalize OnFinalizeFunc, opts ...CmderOption) (*Cmder, error) {
 	c := &Cmder{
-		cfg:   cfg,
-		viper: viper.New(),
+		cfg:      cfg,
+		viper:    viper.New(),
+		handlers: make(map[string]commandHandler),
+		fs:       afero.NewOsFs(),
+	}
+
+	if run != nil {
+		c.handlers[""] = newCommandHandler(run)
 	}
 
 	for _, opt := range opts {
@@ -48,21 +113,63 @@ func NewCmder(cfg any, onFinalize OnFinalizeFunc, opts ...CmderOption) (*Cmder,
 	}
 
 	c.cobra = &cobra.Command{
-		Short:   c.shortDesc,
-		Long:    c.longDesc,
-		Version: c.version,
-		PreRunE: c.preRunE,
-		RunE:    c.runE,
+		Short:             c.shortDesc,
+		Long:              c.longDesc,
+		Version:           c.version,
+		PersistentPreRunE: c.preRunE,
 	}
+	c.cobra.SetFlagErrorFunc(redactFlagError)
 
-	cobra.OnFinalize(func() {
-		onFinalize(c.cfg)
-	})
+	cmds, err := createCommandItems(*cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.initCommands(cmds); err != nil {
+		return nil, err
+	}
+
+	if err := c.initWatch(); err != nil {
+		return nil, err
+	}
 
-	if err := c.init(createConfigItems(cfg)); err != nil {
+	items, err := createConfigItems(*cfg)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := c.init(items); err != nil {
+		return nil, err
+	}
+
+	if err := c.addConfigFlag(); err != nil {
+		return nil, err
+	}
+
+	if c.explainConfig {
+		if err := c.addExplainConfigFlag(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.printConfig {
+		if err := c.addPrintConfigFlag(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.configInit {
+		if err := c.addConfigInitCommand(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.configSchema {
+		if err := c.addConfigSchemaCommand(); err != nil {
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -73,19 +180,198 @@ func (c *Cmder) Cobra() *cobra.Command {
 
 // Returns the Viper instance.
 func (c *Cmder) Viper() *viper.Viper {
+	root := c.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
 	return c.viper
 }
 
 // Executes the command.
 func (c *Cmder) Execute() error {
-	return c.cobra.Execute()
+	return c.ExecuteContext(context.Background())
+}
+
+// Executes the command with the given context. The context is passed to the run callback.
+// Errors raised while parsing the command line are wrapped with ErrUsage and errors raised
+// while reading or decoding the configuration are wrapped with ErrConfig.
+func (c *Cmder) ExecuteContext(ctx context.Context) error {
+	c.parsed = false
+
+	// The deprecated inputs are reported once per run by warnDeprecated. The commands added
+	// after New, with AddCommand or the config commands, are silenced here too.
+	if c.hasDeprecations() {
+		discardFlagWarnings(c.cobra)
+	}
+
+	if err := c.cobra.ExecuteContext(ctx); err != nil {
+		if !c.parsed {
+			return &cmderError{kind: ErrUsage, err: err}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// initCommands creates the subcommands and attaches the run callbacks.
+// A command without a callback only prints its help, or its config.
+func (c *Cmder) initCommands(cmds []commandItem) error {
+	c.commands = map[string]*cobra.Command{"": c.cobra}
+	keys := map[string]string{"": ""}
+
+	for _, cmd := range cmds {
+		cc := &cobra.Command{
+			Use:   cmd.name,
+			Short: cmd.desc,
+		}
+
+		c.commands[cmd.parent].AddCommand(cc)
+		c.commands[cmd.key] = cc
+		keys[cmd.path] = cmd.key
+	}
+
+	for path, h := range c.handlers {
+		key, ok := keys[path]
+		if !ok {
+			return fmt.Errorf("unknown command %q", path)
+		}
+
+		if cfgType := reflect.TypeOf(c.cfg); h.cfgType != cfgType {
+			return fmt.Errorf("command %q expects config type %s, got %s", path, h.cfgType, cfgType)
+		}
+
+		c.commands[key].RunE = c.runE(key, h)
+	}
+
+	for key, cc := range c.commands {
+		if cc.RunE == nil {
+			cc.RunE = c.helpE(key)
+		}
+	}
+
+	return nil
+}
+
+// initArgs sets the arity check and the usage line of the commands with positional arguments.
+func (c *Cmder) initArgs() error {
+	c.args = make(map[string]positionalArgs)
+
+	for key, cc := range c.commands {
+		args, err := createPositionalArgs(c.items, key)
+		if err != nil {
+			return err
+		}
+
+		if args.empty() {
+			continue
+		}
+
+		name := cc.Name()
+		if key == "" && cc.Use == "" {
+			name = filepath.Base(os.Args[0])
+		}
+
+		cc.Use = name + " " + args.usage()
+		cc.Args = args.validator()
+		c.args[key] = args
+	}
+
+	return nil
+}
+
+// AddCommand mounts a child Cmder as a subcommand. The child shares the Viper instance and
+// the config file of its parent, and its keys and environment variables are namespaced under
+// the command name, or the namespace set with WithNamespace. Only the callback of the selected
+// command is run.
+func (c *Cmder) AddCommand(name string, child *Cmder) error {
+	if child.parent != nil {
+		return fmt.Errorf("command %q is already added to another command", name)
+	}
+
+	if err := checkShorthands(c.cobra, child.cobra); err != nil {
+		return fmt.Errorf("command %q: %w", name, err)
+	}
+
+	_, args, _ := strings.Cut(child.cobra.Use, " ")
+	child.cobra.Use = strings.TrimSpace(name + " " + args)
+
+	if err := child.mount(c); err != nil {
+		return err
+	}
+
+	c.cobra.AddCommand(child.cobra)
+	c.children = append(c.children, child)
+
+	return nil
+}
+
+// mount binds a child and its own children to the Viper instance of the parent.
+// The config file is read by the root command so the child hook is removed.
+func (c *Cmder) mount(parent *Cmder) error {
+	namespace := c.cobra.Name()
+	if c.namespace != nil {
+		namespace = *c.namespace
+	}
+
+	c.parent = parent
+	c.viper = parent.viper
+	c.keyPrefix = parent.keyPrefix
+	if namespace != "" {
+		c.keyPrefix += strings.ToLower(namespace) + "."
+	}
+
+	c.cobra.PersistentPreRunE = nil
+
+	if err := c.bind(); err != nil {
+		return err
+	}
+
+	for _, child := range c.children {
+		if err := child.mount(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cmder) root() *Cmder {
+	if c.parent == nil {
+		return c
+	}
+
+	return c.parent.root()
+}
+
+// key returns the Viper key of an item, including the namespace of the Cmder.
+func (c *Cmder) key(item configItem) string {
+	return c.keyPrefix + item.name
 }
 
 func (c *Cmder) init(items []configItem) error {
+	c.items = items
+
 	for _, item := range items {
 		if err := c.addCliFlag(item); err != nil {
 			return err
 		}
+	}
+
+	if err := c.initArgs(); err != nil {
+		return err
+	}
+
+	return c.bind()
+}
+
+// bind sets the defaults and binds the flags and the environment variables of the items to Viper.
+func (c *Cmder) bind() error {
+	for _, item := range c.items {
+		if item.arg != "" {
+			continue
+		}
 
 		if item.hasDefaultValue {
 			if err := c.setDefaultConfigValue(item); err != nil {
@@ -102,42 +388,101 @@ func (c *Cmder) init(items []configItem) error {
 }
 
 func (c *Cmder) addCliFlag(item configItem) error {
-	if item.isHidden {
+	if item.isHidden || item.arg != "" {
 		return nil
 	}
 
-	flagName := toFlagName(item.name)
+	// The help doesn't show the default of a secret, viper still resolves it.
+	if item.isSecret {
+		item.defaultValue = reflect.Zero(item.typ).Interface()
+	}
 
-	switch item.kind {
-	case reflect.String:
-		c.cobra.Flags().String(flagName, item.defaultValue.(string), item.desc)
-	case reflect.Bool:
-		c.cobra.Flags().Bool(flagName, item.defaultValue.(bool), item.desc)
-	case reflect.Int:
-		c.cobra.Flags().Int(flagName, item.defaultValue.(int), item.desc)
-	case reflect.Float32:
-		c.cobra.Flags().Float32(flagName, item.defaultValue.(float32), item.desc)
+	flagName := item.flagName()
+	flags := c.commands[item.command].PersistentFlags()
+
+	if flags.Lookup(flagName) != nil {
+		return fmt.Errorf("%s: flag --%s is already defined", item.name, flagName)
+	}
+
+	if err := c.checkShorthand(item); err != nil {
+		return err
+	}
+
+	switch {
+	case item.parser != nil:
+		flags.StringP(flagName, item.short, item.formatValue(item.defaultValue), item.desc)
+	case item.kind == reflect.Slice && item.elem.kind == reflect.Int:
+		flags.IntSliceP(flagName, item.short, cast.ToIntSlice(item.defaultValue), item.desc)
+	case item.kind == reflect.Slice:
+		flags.StringSliceP(flagName, item.short, item.formatValues(item.defaultValue), item.desc)
+	case item.kind == reflect.Map:
+		flags.StringToStringP(flagName, item.short, item.formatEntries(item.defaultValue), item.desc)
+	case item.typ == durationType:
+		flags.DurationP(flagName, item.short, item.defaultValue.(time.Duration), item.desc)
+	case item.typ == timeType:
+		flags.StringP(flagName, item.short, item.formatValue(item.defaultValue), item.desc)
 	default:
-		return fmt.Errorf("unsupported type %s", item.kind)
+		if err := addScalarCliFlag(flags, flagName, item); err != nil {
+			return err
+		}
+	}
+
+	if err := c.addFlagChoices(item, flagName); err != nil {
+		return err
+	}
+
+	if err := c.addDeprecatedFlags(item, flags, flagName); err != nil {
+		return err
+	}
+
+	// The values of the secret flags are redacted from the parse errors by redactFlagError.
+	if item.isSecret {
+		for _, name := range append([]string{flagName}, item.aliasFlagNames()...) {
+			if err := flags.SetAnnotation(name, secretAnnotation, []string{"true"}); err != nil {
+				return err
+			}
+		}
 	}
 
 	if item.isRequired {
-		c.cobra.MarkFlagRequired(item.name)
+		if err := cobra.MarkFlagRequired(flags, flagName); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (c *Cmder) setDefaultConfigValue(item configItem) error {
+func addScalarCliFlag(flags *pflag.FlagSet, flagName string, item configItem) error {
 	switch item.kind {
 	case reflect.String:
-		c.viper.SetDefault(item.name, item.defaultValue.(string))
+		flags.StringP(flagName, item.short, item.defaultValue.(string), item.desc)
 	case reflect.Bool:
-		c.viper.SetDefault(item.name, item.defaultValue.(bool))
+		flags.BoolP(flagName, item.short, item.defaultValue.(bool), item.desc)
 	case reflect.Int:
-		c.viper.SetDefault(item.name, item.defaultValue.(int))
+		flags.IntP(flagName, item.short, item.defaultValue.(int), item.desc)
+	case reflect.Int8:
+		flags.Int8P(flagName, item.short, item.defaultValue.(int8), item.desc)
+	case reflect.Int16:
+		flags.Int16P(flagName, item.short, item.defaultValue.(int16), item.desc)
+	case reflect.Int32:
+		flags.Int32P(flagName, item.short, item.defaultValue.(int32), item.desc)
+	case reflect.Int64:
+		flags.Int64P(flagName, item.short, item.defaultValue.(int64), item.desc)
+	case reflect.Uint:
+		flags.UintP(flagName, item.short, item.defaultValue.(uint), item.desc)
+	case reflect.Uint8:
+		flags.Uint8P(flagName, item.short, item.defaultValue.(uint8), item.desc)
+	case reflect.Uint16:
+		flags.Uint16P(flagName, item.short, item.defaultValue.(uint16), item.desc)
+	case reflect.Uint32:
+		flags.Uint32P(flagName, item.short, item.defaultValue.(uint32), item.desc)
+	case reflect.Uint64:
+		flags.Uint64P(flagName, item.short, item.defaultValue.(uint64), item.desc)
 	case reflect.Float32:
-		c.viper.SetDefault(item.name, item.defaultValue.(float32))
+		flags.Float32P(flagName, item.short, item.defaultValue.(float32), item.desc)
+	case reflect.Float64:
+		flags.Float64P(flagName, item.short, item.defaultValue.(float64), item.desc)
 	default:
 		return fmt.Errorf("unsupported type %s", item.kind)
 	}
@@ -145,14 +490,94 @@ func (c *Cmder) setDefaultConfigValue(item configItem) error {
 	return nil
 }
 
+// checkShorthand reports a shorthand already used by the command of the item, by one of
+// its parents or by one of its subcommands, since their flags are merged. The -h shorthand
+// is reserved for the help.
+func (c *Cmder) checkShorthand(item configItem) error {
+	if item.short == "" {
+		return nil
+	}
+
+	if item.short == "h" {
+		return fmt.Errorf("%s: shorthand -h is reserved for the help", item.name)
+	}
+
+	cmd := c.commands[item.command]
+
+	var used *pflag.Flag
+	for p := cmd.Parent(); p != nil && used == nil; p = p.Parent() {
+		used = p.PersistentFlags().ShorthandLookup(item.short)
+	}
+
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		if used == nil {
+			used = cmd.PersistentFlags().ShorthandLookup(item.short)
+		}
+		for _, sub := range cmd.Commands() {
+			visit(sub)
+		}
+	}
+	visit(cmd)
+
+	if used != nil {
+		return fmt.Errorf("%s: shorthand -%s is already used by --%s", item.name, item.short, used.Name)
+	}
+
+	return nil
+}
+
+// checkShorthands checks that the flags of a child command and of its subcommands don't reuse
+// the shorthand of a persistent flag of the parent or of its parents, since cobra merges them.
+func checkShorthands(parent, child *cobra.Command) error {
+	inherited := map[string]*pflag.Flag{}
+	for p := parent; p != nil; p = p.Parent() {
+		p.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+			if _, ok := inherited[f.Shorthand]; f.Shorthand != "" && !ok {
+				inherited[f.Shorthand] = f
+			}
+		})
+	}
+
+	var err error
+	check := func(f *pflag.Flag) {
+		if used, ok := inherited[f.Shorthand]; ok && err == nil && used.Name != f.Name {
+			err = fmt.Errorf("shorthand -%s of --%s is already used by --%s", f.Shorthand, f.Name, used.Name)
+		}
+	}
+
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(check)
+		cmd.PersistentFlags().VisitAll(check)
+		for _, sub := range cmd.Commands() {
+			visit(sub)
+		}
+	}
+	visit(child)
+
+	return err
+}
+
+func (c *Cmder) setDefaultConfigValue(item configItem) error {
+	if item.typ == nil {
+		return fmt.Errorf("unsupported type %s", item.kind)
+	}
+
+	c.viper.SetDefault(c.key(item), item.defaultValue)
+
+	return nil
+}
+
 func (c *Cmder) connectViperAndCobra(item configItem) error {
 	if !item.isHidden {
-		if err := c.viper.BindPFlag(item.name, c.cobra.Flags().Lookup(toFlagName(item.name))); err != nil {
+		flags := c.commands[item.command].PersistentFlags()
+		if err := c.viper.BindPFlag(c.key(item), flags.Lookup(item.flagName())); err != nil {
 			return err
 		}
 	}
 
-	if err := c.viper.BindEnv(item.name, toEnvName(c.envPrefix, item.name)); err != nil {
+	if err := c.viper.BindEnv(append([]string{c.key(item)}, c.envNames(item)...)...); err != nil {
 		return err
 	}
 
@@ -160,7 +585,39 @@ func (c *Cmder) connectViperAndCobra(item configItem) error {
 }
 
 func toFlagName(name string) string {
-	return strings.ToLower(strings.Replace(name, ".", "-", -1))
+	return strings.ToLower(strings.NewReplacer(".", "-", "_", "-").Replace(name))
+}
+
+// envNames returns the environment variables of the item by precedence, from its `env`
+// tag or from its key, then from its old keys. A secret can also be read from the file
+// named by each variable suffixed by _FILE.
+func (c *Cmder) envNames(item configItem) []string {
+	names := append(c.keyEnvNames(item), c.aliasEnvNames(item)...)
+
+	return c.withSecretFiles(item, names)
+}
+
+// keyEnvNames returns the environment variables of the item from its `env` tag or from its key.
+func (c *Cmder) keyEnvNames(item configItem) []string {
+	if item.env != nil {
+		return append([]string{}, item.env...)
+	}
+
+	return []string{toEnvName(c.root().envPrefix, c.key(item))}
+}
+
+// withSecretFiles adds the _FILE variables of a secret after the variables holding its value.
+func (c *Cmder) withSecretFiles(item configItem, names []string) []string {
+	if !item.isSecret {
+		return names
+	}
+
+	files := make([]string, len(names))
+	for i, name := range names {
+		files[i] = name + secretFileSuffix
+	}
+
+	return append(names, files...)
 }
 
 func toEnvName(prefix, name string) string {
@@ -172,19 +629,212 @@ func toEnvName(prefix, name string) string {
 }
 
 func (c *Cmder) preRunE(cmd *cobra.Command, _ []string) error {
-	if err := c.viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+	c.applyFlagAliases()
+
+	// Cobra validates the flags after this hook, validate them first
+	// so that their errors are reported as usage errors.
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		return err
+	}
+
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		return err
+	}
+
+	c.root().parsed = true
+
+	if err := c.readConfig(cmd); err != nil {
+		return &cmderError{kind: ErrConfig, err: err}
+	}
+
+	c.warnDeprecated(cmd.ErrOrStderr())
+
+	return nil
+}
+
+func (c *Cmder) runE(key string, h commandHandler) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if ok, err := c.showConfig(cmd, key); ok {
+			return err
+		}
+
+		cfg := reflect.ValueOf(c.cfg).Elem()
+
+		if err := c.decode(cfg); err != nil {
+			return &cmderError{kind: ErrConfig, err: err}
+		}
+
+		if err := c.decodeArgs(c.args[key], args); err != nil {
+			return &cmderError{kind: ErrUsage, err: err}
+		}
+
+		if err := c.validate(cfg, key, args); err != nil {
+			return &cmderError{kind: ErrConfig, err: err}
+		}
+
+		if c.watch != nil {
+			stop, err := c.startWatch(cmd, key, args)
+			if err != nil {
+				return &cmderError{kind: ErrConfig, err: fmt.Errorf("watch config: %w", err)}
+			}
+			defer stop()
+		}
+
+		return h.run(cmd.Context(), c.cfg)
+	}
+}
+
+// helpE prints the help of a command without a callback, or its config with --explain-config
+// and --print-config.
+func (c *Cmder) helpE(key string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		if ok, err := c.showConfig(cmd, key); ok {
 			return err
 		}
+
+		return cmd.Help()
+	}
+}
+
+// showConfig explains or prints the config of the command when --explain-config or
+// --print-config is set, and reports whether it did. The config is decoded, not validated.
+func (c *Cmder) showConfig(cmd *cobra.Command, key string) (bool, error) {
+	if c.root().explainConfig && explainRequested(cmd) {
+		return true, c.explain(cmd.OutOrStdout(), key)
+	}
+
+	format, ok := printRequested(cmd)
+	if !ok || !c.root().printConfig {
+		return false, nil
+	}
+
+	if err := c.decode(reflect.ValueOf(c.cfg).Elem()); err != nil {
+		return true, &cmderError{kind: ErrConfig, err: err}
+	}
+
+	return true, c.writeConfig(cmd.OutOrStdout(), key, format)
+}
+
+// decode resolves every config item through viper and stores the value in the config struct cfg.
+// Optional items are skipped unless a flag, an environment variable, a config file or a default
+// sets them, so that their pointer, or the pointer of their section, stays nil.
+func (c *Cmder) decode(cfg reflect.Value) error {
+	for _, item := range c.items {
+		if item.arg != "" {
+			continue
+		}
+
+		key := c.key(item)
+
+		if item.optional && !c.viper.IsSet(key) {
+			continue
+		}
+
+		raw := c.viper.Get(key)
+		if raw == nil {
+			continue
+		}
+
+		if file, ok := c.secretFile(item, raw); ok {
+			secret, err := c.readSecret(file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			raw = secret
+		}
+
+		value, err := item.decode(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid value: %w", key, item.redactError(err, raw))
+		}
+
+		field, ok := fieldByIndex(cfg, item.index)
+		if !ok {
+			continue
+		}
+
+		setField(field, reflect.ValueOf(value))
 	}
 
 	return nil
 }
 
-func (c *Cmder) runE(cmd *cobra.Command, _ []string) error {
-	if err := c.viper.Unmarshal(&c.cfg); err != nil {
-		return err
+// decodeArgs stores the positional arguments in the config struct.
+// Missing optional arguments keep their default value.
+func (c *Cmder) decodeArgs(pargs positionalArgs, args []string) error {
+	cfg := reflect.ValueOf(c.cfg).Elem()
+
+	for i, item := range pargs.indexed {
+		var value any
+
+		switch {
+		case i < len(args):
+			v, err := item.parse(args[i])
+			if err != nil {
+				return fmt.Errorf("invalid argument %q for \"<%s>\": %w", args[i], item.argName(), err)
+			}
+			value = v
+		case item.hasDefaultValue:
+			value = item.defaultValue
+		default:
+			continue
+		}
+
+		if field, ok := fieldByIndex(cfg, item.index); ok {
+			setField(field, reflect.ValueOf(value))
+		}
+	}
+
+	if rest := pargs.rest; rest != nil && (len(args) > len(pargs.indexed) || rest.hasDefaultValue) {
+		value := rest.defaultValue
+
+		if len(args) > len(pargs.indexed) {
+			values := make([]any, 0, len(args)-len(pargs.indexed))
+			for _, arg := range args[len(pargs.indexed):] {
+				values = append(values, arg)
+			}
+
+			v, err := rest.decodeSlice(values)
+			if err != nil {
+				return fmt.Errorf("invalid argument for \"%s...\": %w", rest.argName(), err)
+			}
+			value = v
+		}
+
+		if field, ok := fieldByIndex(cfg, rest.index); ok {
+			setField(field, reflect.ValueOf(value))
+		}
 	}
 
 	return nil
 }
+
+// fieldByIndex returns the nested field of v for the index sequence,
+// allocating the nil pointers to structs along the way.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, v.CanSet()
+}
+
+// setField sets a decoded value, allocating a new pointer for the pointer fields.
+func setField(field, value reflect.Value) {
+	if field.Kind() == reflect.Pointer && value.Type() != field.Type() {
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(value.Convert(field.Type().Elem()))
+		value = ptr
+	}
+
+	field.Set(value.Convert(field.Type()))
+}
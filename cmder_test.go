@@ -16,9 +16,15 @@ package gocmder
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/suite"
@@ -29,8 +35,8 @@ type cmderTestSuite struct {
 	buf bytes.Buffer
 }
 
-func (s *cmderTestSuite) TestNewCmder() {
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {})
+func (s *cmderTestSuite) TestNew() {
+	cmder, err := New(func(context.Context, *rootConfig) error { return nil })
 
 	s.NoError(err)
 
@@ -42,8 +48,14 @@ func (s *cmderTestSuite) TestNewCmder() {
 	s.NoError(err)
 }
 
-func (s *cmderTestSuite) TestNewCmderWithoutRequiredArgs() {
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {})
+func (s *cmderTestSuite) TestNewWithNonStructConfig() {
+	_, err := New(func(context.Context, *string) error { return nil })
+
+	s.EqualError(err, "unsupported config type string, expected a struct")
+}
+
+func (s *cmderTestSuite) TestNewWithoutRequiredArgs() {
+	cmder, err := New(func(context.Context, *rootConfig) error { return nil })
 
 	s.NoError(err)
 
@@ -53,10 +65,12 @@ func (s *cmderTestSuite) TestNewCmderWithoutRequiredArgs() {
 	err = cmder.Execute()
 
 	s.EqualError(err, "required flag(s) \"foo\" not set")
+	s.ErrorIs(err, ErrUsage)
+	s.Equal(ExitUsage, cmder.ExitCode(err))
 }
 
-func (s *cmderTestSuite) TestNewCmderWithInvalidArgs() {
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {})
+func (s *cmderTestSuite) TestNewWithInvalidArgs() {
+	cmder, err := New(func(context.Context, *rootConfig) error { return nil })
 
 	s.NoError(err)
 
@@ -68,14 +82,14 @@ func (s *cmderTestSuite) TestNewCmderWithInvalidArgs() {
 	s.EqualError(err, "invalid argument \"bar\" for \"--bar\" flag: strconv.ParseInt: parsing \"bar\": invalid syntax")
 }
 
-func (s *cmderTestSuite) TestNewCmderValidArgs() {
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {
-		c := cfg.(rootConfig)
+func (s *cmderTestSuite) TestNewValidArgs() {
+	cmder, err := New(func(_ context.Context, c *rootConfig) error {
 		s.Equal("im a foo", c.Foo)
 		s.Equal(2, c.Bar)
 		s.Equal(float32(1.2), c.Child.Decimal)
 		s.Equal(true, c.Child.Boolean)
 		s.Equal("hide and seek", c.Child.Hidden)
+		return nil
 	})
 
 	s.NoError(err)
@@ -88,8 +102,8 @@ func (s *cmderTestSuite) TestNewCmderValidArgs() {
 	s.NoError(err)
 }
 
-func (s *cmderTestSuite) TestNewCmderWithVersionOptions() {
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {}, WithVersion("1.2.3"))
+func (s *cmderTestSuite) TestNewWithVersionOptions() {
+	cmder, err := New(func(context.Context, *rootConfig) error { return nil }, WithVersion("1.2.3"))
 
 	s.NoError(err)
 
@@ -103,16 +117,16 @@ func (s *cmderTestSuite) TestNewCmderWithVersionOptions() {
 	s.Equal("version 1.2.3\n", string(version))
 }
 
-func (s *cmderTestSuite) TestNewCmderWithEnvVariable_OverrideDefaultValue() {
-	onfinalizeCalled := false
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {
-		c := cfg.(rootConfig)
+func (s *cmderTestSuite) TestNewWithEnvVariable_OverrideDefaultValue() {
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *rootConfig) error {
 		s.Equal("im a foo", c.Foo)
 		s.Equal(3, c.Bar)
 		s.Equal(float32(3.14), c.Child.Decimal)
 		s.Equal(false, c.Child.Boolean)
 		s.Equal("i found you", c.Child.Hidden)
-		onfinalizeCalled = true
+		runCalled = true
+		return nil
 	}, WithPrefix("TEST"))
 
 	s.NoError(err)
@@ -128,10 +142,10 @@ func (s *cmderTestSuite) TestNewCmderWithEnvVariable_OverrideDefaultValue() {
 	err = cmder.Execute()
 	s.NoError(err)
 
-	s.True(onfinalizeCalled)
+	s.True(runCalled)
 }
 
-func (s *cmderTestSuite) TestNewCmderWithConfigFile() {
+func (s *cmderTestSuite) TestNewWithConfigFile() {
 	fs := afero.NewMemMapFs()
 
 	dir, err := os.Getwd()
@@ -155,27 +169,736 @@ child:
 
 	s.NoError(err)
 
-	onfinalizeCalled := false
-	cmder, err := NewCmder(rootConfig{}, func(cfg any) {
-		c := cfg.(rootConfig)
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *rootConfig) error {
 		s.Equal("im a foo", c.Foo)
 		s.Equal(3, c.Bar)
 		s.Equal(float32(3.14), c.Child.Decimal)
 		s.Equal(false, c.Child.Boolean)
 		s.Equal("i found you", c.Child.Hidden)
-		onfinalizeCalled = true
+		runCalled = true
+		return nil
 	}, WithFS(fs), WithConfigFile(filepath.Join(dir, "config.yaml")))
 
 	s.NoError(err)
 
 	cmder.cobra.SetArgs([]string{"--foo", "im a foo"})
 	cmder.cobra.SetOutput(&s.buf)
-	
 
 	err = cmder.Execute()
 
 	s.NoError(err)
-	s.True(onfinalizeCalled)
+	s.True(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewRunErrorIsPropagated() {
+	runErr := errors.New("boom")
+
+	cmder, err := New(func(context.Context, *rootConfig) error { return runErr })
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--foo", "foo"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.ErrorIs(err, runErr)
+	s.Equal(ExitFailure, cmder.ExitCode(err))
+}
+
+func (s *cmderTestSuite) TestNewRunNotCalledOnHelp() {
+	runCalled := false
+	cmder, err := New(func(context.Context, *rootConfig) error {
+		runCalled = true
+		return nil
+	})
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--help"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.Execute())
+	s.False(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewRunReceivesContext() {
+	type ctxKey struct{}
+
+	cmder, err := New(func(ctx context.Context, _ *rootConfig) error {
+		s.Equal("value", ctx.Value(ctxKey{}))
+		return nil
+	})
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--foo", "foo"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.ExecuteContext(context.WithValue(context.Background(), ctxKey{}, "value")))
+}
+
+func (s *cmderTestSuite) TestExecuteUsageError() {
+	runCalled := false
+	cmder, err := New(func(context.Context, *rootConfig) error {
+		runCalled = true
+		return nil
+	})
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--foo", "foo", "--unknown"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.EqualError(err, "unknown flag: --unknown")
+	s.ErrorIs(err, ErrUsage)
+	s.Equal(ExitUsage, cmder.ExitCode(err))
+	s.False(runCalled)
+}
+
+func (s *cmderTestSuite) TestExecuteConfigError() {
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte("foo: [unclosed"), 0644))
+
+	runCalled := false
+	cmder, err := New(func(context.Context, *rootConfig) error {
+		runCalled = true
+		return nil
+	}, WithFS(fs), WithConfigFile("/config.yaml"), WithExitCode(ErrConfig, 3))
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--foo", "foo"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.ErrorIs(err, ErrConfig)
+	s.Equal(3, cmder.ExitCode(err))
+	s.False(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewWithScalarKinds() {
+	type scalars struct {
+		Small  int8    `default:"1"`
+		Big    int64   `default:"2"`
+		Count  uint    `default:"3"`
+		Port   uint16  `default:"4"`
+		Ratio  float64 `default:"0.5"`
+		Offset int32
+	}
+
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *scalars) error {
+		s.Equal(int8(-5), c.Small)
+		s.Equal(int64(1<<40), c.Big)
+		s.Equal(uint(3), c.Count)
+		s.Equal(uint16(8080), c.Port)
+		s.Equal(0.25, c.Ratio)
+		s.Equal(int32(0), c.Offset)
+		runCalled = true
+		return nil
+	}, WithPrefix("TEST"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_PORT", "8080")
+	s.T().Setenv("TEST_RATIO", "0.25")
+
+	cmder.Cobra().SetArgs([]string{"--small", "-5", "--big", "1099511627776"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.Execute())
+	s.True(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewWithOutOfRangeFlag() {
+	type scalars struct {
+		Small int8
+	}
+
+	cmder, err := New(func(context.Context, *scalars) error { return nil })
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--small", "128"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.ErrorIs(err, ErrUsage)
+}
+
+func (s *cmderTestSuite) TestNewWithTimeFields() {
+	type timeConfig struct {
+		Timeout  time.Duration `default:"30s"`
+		Interval time.Duration
+		Deadline time.Time
+		Day      time.Time `layout:"2006-01-02" default:"2023-01-02"`
+		Started  time.Time
+	}
+
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte("started: 2023-03-04T05:06:07Z\n"), 0644))
+
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *timeConfig) error {
+		s.Equal(30*time.Second, c.Timeout)
+		s.Equal(1500*time.Millisecond, c.Interval)
+		s.Equal(time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), c.Deadline.UTC())
+		s.Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), c.Day)
+		s.Equal(time.Date(2023, 3, 4, 5, 6, 7, 0, time.UTC), c.Started.UTC())
+		runCalled = true
+		return nil
+	}, WithPrefix("TEST"), WithFS(fs), WithConfigFile("/config.yaml"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_DEADLINE", "2023-01-02T15:04:05Z")
+
+	cmder.Cobra().SetArgs([]string{"--interval", "1.5s"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.Execute())
+	s.True(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewWithInvalidTimeEnv() {
+	type timeConfig struct {
+		Day time.Time `layout:"2006-01-02"`
+	}
+
+	cmder, err := New(func(context.Context, *timeConfig) error { return nil }, WithPrefix("TEST"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_DAY", "02/01/2023")
+
+	cmder.Cobra().SetArgs([]string{})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorContains(err, "day: invalid value")
+}
+
+func (s *cmderTestSuite) TestNewWithCollections() {
+	type collections struct {
+		Tags      []string          `default:"a,b"`
+		Ports     []int             `default:"80,443"`
+		Hosts     []string          `sep:";"`
+		Weights   []float64         `default:"0.5"`
+		Labels    map[string]string `default:"env=dev,team=core"`
+		Limits    map[string]int
+		Timeouts  []time.Duration
+		Untouched []uint16 `default:"1,2"`
+	}
+
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte(`
+weights: [1.5, 2.5]
+limits:
+  cpu: 2
+  memory: 512
+timeouts:
+  - 1s
+  - 2m
+`), 0644))
+
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *collections) error {
+		s.Equal([]string{"x", "y", "z"}, c.Tags)
+		s.Equal([]int{80, 443}, c.Ports)
+		s.Equal([]string{"a.example.com", "b.example.com"}, c.Hosts)
+		s.Equal([]float64{1.5, 2.5}, c.Weights)
+		s.Equal(map[string]string{"env": "prod"}, c.Labels)
+		s.Equal(map[string]int{"cpu": 2, "memory": 512}, c.Limits)
+		s.Equal([]time.Duration{time.Second, 2 * time.Minute}, c.Timeouts)
+		s.Equal([]uint16{1, 2}, c.Untouched)
+		runCalled = true
+		return nil
+	}, WithPrefix("TEST"), WithFS(fs), WithConfigFile("/config.yaml"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_HOSTS", "a.example.com; b.example.com")
+	s.T().Setenv("TEST_LABELS", "env=prod")
+
+	cmder.Cobra().SetArgs([]string{"--tags", "x", "--tags", "y,z"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.Execute())
+	s.True(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewWithInvalidSliceElement() {
+	type collections struct {
+		Ports []uint16
+	}
+
+	cmder, err := New(func(context.Context, *collections) error { return nil }, WithPrefix("TEST"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_PORTS", "80,70000")
+
+	cmder.Cobra().SetArgs([]string{})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorContains(err, "ports: invalid value: element 1")
+}
+
+func (s *cmderTestSuite) TestNewWithTextTypes() {
+	type textConfig struct {
+		Addr    net.IP   `default:"127.0.0.1"`
+		Origin  *url.URL `default:"https://example.com"`
+		Subnets []netip.Prefix
+		Limit   byteSize `default:"1KB"`
+		Color   color
+		Max     byteSize
+	}
+
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte("subnets: [10.0.0.0/8, 192.168.0.0/16]\nlimit: 4KB\n"), 0644))
+
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *textConfig) error {
+		s.Equal(net.ParseIP("10.1.2.3"), c.Addr)
+		s.Equal("https://example.com", c.Origin.String())
+		s.Equal([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}, c.Subnets)
+		s.Equal(byteSize{bytes: 4096}, c.Limit)
+		s.Equal(color("blue"), c.Color)
+		s.Equal(byteSize{}, c.Max)
+		runCalled = true
+		return nil
+	}, WithPrefix("TEST"), WithFS(fs), WithConfigFile("/config.yaml"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_COLOR", "Blue")
+
+	cmder.Cobra().SetArgs([]string{"--addr", "10.1.2.3"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.Execute())
+	s.True(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewWithInvalidTextValue() {
+	type textConfig struct {
+		Color color
+	}
+
+	cmder, err := New(func(context.Context, *textConfig) error { return nil })
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{"--color", "pink"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	err = cmder.Execute()
+
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, "color: invalid value: unknown color \"pink\"")
+}
+
+func (s *cmderTestSuite) TestNewWithPointers() {
+	type section struct {
+		Port int `default:"80"`
+	}
+
+	type optionalSection struct {
+		Host string
+		Port int
+	}
+
+	type pointerConfig struct {
+		Unset    *int
+		Flag     *int
+		Env      *bool
+		File     *string
+		Default  *time.Duration `default:"5s"`
+		Zero     *int
+		Server   *section
+		Proxy    *optionalSection
+		Database *optionalSection
+	}
+
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte("file: from file\ndatabase:\n  port: 5432\n"), 0644))
+
+	runCalled := false
+	cmder, err := New(func(_ context.Context, c *pointerConfig) error {
+		s.Nil(c.Unset)
+		s.Equal(42, *c.Flag)
+		s.Equal(false, *c.Env)
+		s.Equal("from file", *c.File)
+		s.Equal(5*time.Second, *c.Default)
+		s.Equal(0, *c.Zero)
+		s.Equal(&section{Port: 80}, c.Server)
+		s.Nil(c.Proxy)
+		s.Equal(&optionalSection{Port: 5432}, c.Database)
+		runCalled = true
+		return nil
+	}, WithPrefix("TEST"), WithFS(fs), WithConfigFile("/config.yaml"))
+
+	s.NoError(err)
+
+	s.T().Setenv("TEST_ENV", "false")
+
+	cmder.Cobra().SetArgs([]string{"--flag", "42", "--zero", "0"})
+	cmder.Cobra().SetOutput(&s.buf)
+
+	s.NoError(cmder.Execute())
+	s.True(runCalled)
+}
+
+func (s *cmderTestSuite) TestNewWithSubcommands() {
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte("serve:\n  host: example.com\n"), 0644))
+
+	s.T().Setenv("TEST_MIGRATE_UP_STEPS", "3")
+
+	var ran []string
+	newCmder := func() *Cmder {
+		cmder, err := New[appConfig](nil,
+			WithPrefix("TEST"),
+			WithFS(fs),
+			WithConfigFile("/config.yaml"),
+			WithCommand("serve", func(_ context.Context, c *appConfig) error {
+				s.True(c.Verbose)
+				s.Equal("example.com", c.Serve.Host)
+				s.Equal(9000, c.Serve.Port)
+				s.Equal("/tmp/cert.pem", c.Serve.TLS.Cert)
+				ran = append(ran, "serve")
+				return nil
+			}),
+			WithCommand("migrate  up", func(_ context.Context, c *appConfig) error {
+				s.False(c.Verbose)
+				s.Equal("postgres://db", c.Migrate.DSN)
+				s.Equal(3, c.Migrate.Up.Steps)
+				ran = append(ran, "migrate up")
+				return nil
+			}))
+
+		s.NoError(err)
+		cmder.Cobra().SetOutput(&s.buf)
+		return cmder
+	}
+
+	cmder := newCmder()
+	cmder.Cobra().SetArgs([]string{"serve", "--verbose", "--port", "9000", "--tls-cert", "/tmp/cert.pem"})
+	s.NoError(cmder.Execute())
+
+	cmder = newCmder()
+	cmder.Cobra().SetArgs([]string{"migrate", "up", "--dsn", "postgres://db"})
+	s.NoError(cmder.Execute())
+
+	cmder = newCmder()
+	cmder.Cobra().SetArgs([]string{"migrate"})
+	s.NoError(cmder.Execute())
+	s.Contains(s.buf.String(), "up          Apply the migrations")
+
+	s.Equal([]string{"serve", "migrate up"}, ran)
+}
+
+func (s *cmderTestSuite) TestNewWithSubcommandsHelp() {
+	cmder, err := New[appConfig](nil)
+
+	s.NoError(err)
+
+	cmder.Cobra().SetArgs([]string{})
+	cmder.Cobra().SetOutput(&s.buf)
+	s.NoError(cmder.Execute())
+
+	s.Contains(s.buf.String(), "serve       Start the server")
+	s.Contains(s.buf.String(), "--verbose")
+
+	s.buf.Reset()
+	cmder.Cobra().SetArgs([]string{"migrate", "up", "--help"})
+	s.NoError(cmder.Execute())
+
+	s.Contains(s.buf.String(), "--steps int")
+	s.Contains(s.buf.String(), "Global Flags:")
+	s.Contains(s.buf.String(), "--dsn string")
+	s.NotContains(s.buf.String(), "--port")
+}
+
+func (s *cmderTestSuite) TestNewWithUnknownCommand() {
+	_, err := New[appConfig](nil, WithCommand("migrate down", func(context.Context, *appConfig) error { return nil }))
+
+	s.EqualError(err, "unknown command \"migrate down\"")
+}
+
+func (s *cmderTestSuite) TestNewWithCommandConfigTypeMismatch() {
+	_, err := New[appConfig](nil, WithCommand("serve", func(context.Context, *rootConfig) error { return nil }))
+
+	s.EqualError(err, "command \"serve\" expects config type *gocmder.rootConfig, got *gocmder.appConfig")
+}
+
+func (s *cmderTestSuite) TestNewWithShorthands() {
+	type config struct {
+		Verbose bool     `short:"v"`
+		Port    int      `short:"p" default:"8080"`
+		Hosts   []string `short:"H"`
+		Server  struct {
+			Name string `short:"n"`
+		}
+		Serve struct {
+			Workers int `short:"w"`
+		} `cmd:"serve"`
+	}
+
+	var cfg *config
+	cmder, err := New(func(_ context.Context, c *config) error {
+		cfg = c
+		return nil
+	}, WithCommand("serve", func(_ context.Context, c *config) error {
+		cfg = c
+		return nil
+	}))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs([]string{"serve", "-v", "-p", "9000", "-H", "a", "-H", "b", "-n", "api", "-w", "4"})
+	s.NoError(cmder.Execute())
+
+	s.True(cfg.Verbose)
+	s.Equal(9000, cfg.Port)
+	s.Equal([]string{"a", "b"}, cfg.Hosts)
+	s.Equal("api", cfg.Server.Name)
+	s.Equal(4, cfg.Serve.Workers)
+
+	cmder.Cobra().SetArgs([]string{"--help"})
+	s.NoError(cmder.Execute())
+	s.Contains(s.buf.String(), "-p, --port int")
+}
+
+func (s *cmderTestSuite) TestNewWithDuplicateShorthands() {
+	_, err := New[struct {
+		Port   int `short:"p"`
+		Server struct {
+			Path string `short:"p"`
+		}
+	}](nil)
+	s.EqualError(err, "server.path: shorthand -p is already used by --port")
+
+	_, err = New[struct {
+		Serve struct {
+			Port int `short:"p"`
+		} `cmd:"serve"`
+		Path string `short:"p"`
+	}](nil)
+	s.EqualError(err, "path: shorthand -p is already used by --port")
+
+	_, err = New[struct {
+		Host string `short:"h"`
+	}](nil)
+	s.EqualError(err, "host: shorthand -h is reserved for the help")
+
+	_, err = New[struct {
+		Port int `short:"port"`
+	}](nil)
+	s.EqualError(err, `port: invalid short "port", expected a letter or a digit`)
+}
+
+func (s *cmderTestSuite) TestAddCommandWithDuplicateShorthands() {
+	root, err := New[struct {
+		Profile string `short:"p"`
+	}](nil)
+	s.NoError(err)
+
+	child, err := New[struct {
+		Port int `short:"p"`
+	}](nil)
+	s.NoError(err)
+
+	s.EqualError(root.AddCommand("serve", child), `command "serve": shorthand -p of --port is already used by --profile`)
+
+	group, err := New[struct{}](nil)
+	s.NoError(err)
+
+	s.NoError(group.AddCommand("serve", child))
+	s.EqualError(root.AddCommand("group", group), `command "group": shorthand -p of --port is already used by --profile`)
+
+	other, err := New[struct {
+		Profile string `short:"p"`
+	}](nil)
+	s.NoError(err)
+
+	s.NoError(root.AddCommand("other", other))
+
+	root.Cobra().SetOutput(&s.buf)
+	root.Cobra().SetArgs([]string{"other", "-p", "dev"})
+	s.NoError(root.Execute())
+}
+
+func (s *cmderTestSuite) TestAddCommand() {
+	type userConfig struct {
+		Name  string `default:"nobody"`
+		Admin bool
+	}
+
+	type billingConfig struct {
+		Plan string
+	}
+
+	fs := afero.NewMemMapFs()
+	s.NoError(afero.WriteFile(fs, "/config.yaml", []byte(`
+users:
+  name: alice
+accounts:
+  invoices:
+    plan: pro
+`), 0644))
+
+	s.T().Setenv("TEST_USERS_ADMIN", "true")
+
+	var ran []string
+	root, err := New(func(context.Context, *childConfig) error {
+		ran = append(ran, "root")
+		return nil
+	}, WithPrefix("TEST"), WithFS(fs), WithConfigFile("/config.yaml"))
+	s.NoError(err)
+
+	users, err := New(func(_ context.Context, c *userConfig) error {
+		s.Equal(&userConfig{Name: "alice", Admin: true}, c)
+		ran = append(ran, "users")
+		return nil
+	}, WithShortDesc("Manage the users"))
+	s.NoError(err)
+
+	accounts, err := New[userConfig](nil, WithNamespace("accounts"))
+	s.NoError(err)
+
+	invoices, err := New(func(_ context.Context, c *billingConfig) error {
+		s.Equal("pro", c.Plan)
+		ran = append(ran, "invoices")
+		return nil
+	})
+	s.NoError(err)
+
+	s.NoError(root.AddCommand("users", users))
+	s.NoError(accounts.AddCommand("invoices", invoices))
+	s.NoError(root.AddCommand("billing", accounts))
+
+	root.Cobra().SetOutput(&s.buf)
+
+	root.Cobra().SetArgs([]string{"users"})
+	s.NoError(root.Execute())
+
+	root.Cobra().SetArgs([]string{"billing", "invoices"})
+	s.NoError(root.Execute())
+
+	s.Equal([]string{"users", "invoices"}, ran)
+	s.Equal("users", users.Cobra().Name())
+	s.Same(root.Viper(), invoices.Viper())
+
+	s.EqualError(root.AddCommand("people", users), "command \"people\" is already added to another command")
+}
+
+func (s *cmderTestSuite) TestAddCommandUsageError() {
+	root, err := New[rootConfig](nil)
+	s.NoError(err)
+
+	child, err := New(func(context.Context, *rootConfig) error { return nil })
+	s.NoError(err)
+
+	s.NoError(root.AddCommand("child", child))
+
+	root.Cobra().SetOutput(&s.buf)
+	root.Cobra().SetArgs([]string{"child"})
+
+	err = root.Execute()
+
+	s.EqualError(err, "required flag(s) \"foo\" not set")
+	s.ErrorIs(err, ErrUsage)
+
+	root.Cobra().SetArgs([]string{"child", "--foo", "foo", "--bar", "x"})
+	err = root.Execute()
+
+	s.ErrorIs(err, ErrUsage)
+}
+
+func (s *cmderTestSuite) TestNewWithPositionalArgs() {
+	type copyConfig struct {
+		Copy struct {
+			Src     string   `arg:"0"`
+			Dst     string   `arg:"1"`
+			Retries *int     `arg:"2"`
+			Files   []uint16 `arg:"rest"`
+			Force   bool
+		} `cmd:"copy" desc:"Copy files"`
+	}
+
+	var cfg *copyConfig
+	newCmder := func(args ...string) *Cmder {
+		cmder, err := New[copyConfig](nil, WithCommand("copy", func(_ context.Context, c *copyConfig) error {
+			cfg = c
+			return nil
+		}))
+		s.NoError(err)
+
+		cmder.Cobra().SetOutput(&s.buf)
+		cmder.Cobra().SetArgs(args)
+		return cmder
+	}
+
+	s.NoError(newCmder("copy", "a", "b", "3", "1", "2", "--force").Execute())
+	s.Equal("a", cfg.Copy.Src)
+	s.Equal("b", cfg.Copy.Dst)
+	s.Equal(3, *cfg.Copy.Retries)
+	s.Equal([]uint16{1, 2}, cfg.Copy.Files)
+	s.True(cfg.Copy.Force)
+
+	cfg = nil
+	s.NoError(newCmder("copy", "a", "b").Execute())
+	s.Nil(cfg.Copy.Retries)
+	s.Nil(cfg.Copy.Files)
+
+	err := newCmder("copy", "a").Execute()
+	s.EqualError(err, "requires at least 2 arg(s), only received 1")
+	s.ErrorIs(err, ErrUsage)
+
+	err = newCmder("copy", "a", "b", "many").Execute()
+	s.EqualError(err, "invalid argument \"many\" for \"<retries>\": strconv.ParseInt: parsing \"many\": invalid syntax")
+	s.ErrorIs(err, ErrUsage)
+
+	err = newCmder("copy", "a", "b", "1", "-1").Execute()
+	s.ErrorIs(err, ErrUsage)
+
+	s.buf.Reset()
+	s.NoError(newCmder("copy", "--help").Execute())
+	s.Contains(s.buf.String(), "copy <src> <dst> [retries] [files...] [flags]")
+}
+
+func (s *cmderTestSuite) TestAddCommandWithPositionalArgs() {
+	type nameConfig struct {
+		Name string `arg:"0"`
+	}
+
+	root, err := New[childConfig](nil)
+	s.NoError(err)
+
+	child, err := New(func(_ context.Context, c *nameConfig) error {
+		s.Equal("alice", c.Name)
+		return nil
+	})
+	s.NoError(err)
+
+	s.NoError(root.AddCommand("greet", child))
+	s.Equal("greet <name>", child.Cobra().Use)
+
+	root.Cobra().SetOutput(&s.buf)
+	root.Cobra().SetArgs([]string{"greet", "alice"})
+	s.NoError(root.Execute())
 }
 
 func TestCmderTestSuite(t *testing.T) {
@@ -192,6 +915,27 @@ type rootConfig struct {
 	Child childConfig
 }
 
+type appConfig struct {
+	Verbose bool          `desc:"verbose output"`
+	Serve   serveConfig   `cmd:"serve" desc:"Start the server"`
+	Migrate migrateConfig `cmd:"migrate" desc:"Manage the database schema"`
+}
+
+type serveConfig struct {
+	Host string `default:"localhost"`
+	Port int    `default:"8080"`
+	TLS  struct {
+		Cert string
+	}
+}
+
+type migrateConfig struct {
+	DSN string
+	Up  struct {
+		Steps int `desc:"number of migrations"`
+	} `cmd:"up" desc:"Apply the migrations"`
+}
+
 type childConfig struct {
 	Decimal float32 `desc:"decimal" default:"1.2"`
 	Boolean bool    `desc:"boolean" default:"true"`
@@ -0,0 +1,92 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const cmdKey = "cmd"
+
+// commandItem is a config section tagged with `cmd:"name"` that becomes a subcommand.
+type commandItem struct {
+	// name is the name of the command on the command line.
+	name string
+	// path is the names of the command and its parents separated by spaces, e.g. "migrate up".
+	path string
+	// key is the config key of the section, e.g. "migrate.up".
+	key string
+	// parent is the config key of the parent command, empty for the root command.
+	parent string
+	desc   string
+}
+
+func createCommandItems(cfg any) ([]commandItem, error) {
+	commandItems := make([]commandItem, 0)
+	if err := recursivelyExtractCommandItems(reflect.TypeOf(cfg), "", commandItem{}, &commandItems); err != nil {
+		return nil, err
+	}
+	return commandItems, nil
+}
+
+func recursivelyExtractCommandItems(cfgType reflect.Type, prefix string, parent commandItem, cmdItems *[]commandItem) error {
+	if cfgType.Kind() == reflect.Pointer {
+		cfgType = cfgType.Elem()
+	}
+
+	for _, vf := range reflect.VisibleFields(cfgType) {
+		if isSkipped(vf) {
+			continue
+		}
+
+		name, isCmd := vf.Tag.Lookup(cmdKey)
+
+		if !isSection(vf.Type) {
+			if isCmd {
+				return fmt.Errorf("%s: command %q must be a struct", prefix+fieldKey(vf), name)
+			}
+			continue
+		}
+
+		if !isCmd {
+			if err := recursivelyExtractCommandItems(vf.Type, prefix+fieldKey(vf)+".", parent, cmdItems); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" || strings.ContainsAny(name, " .") {
+			return fmt.Errorf("%s: invalid command name %q", prefix+fieldKey(vf), name)
+		}
+
+		cmd := commandItem{
+			name:   name,
+			path:   strings.TrimSpace(parent.path + " " + name),
+			key:    prefix + name,
+			parent: parent.key,
+			desc:   vf.Tag.Get(descKey),
+		}
+
+		*cmdItems = append(*cmdItems, cmd)
+
+		if err := recursivelyExtractCommandItems(vf.Type, cmd.key+".", cmd, cmdItems); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
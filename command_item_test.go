@@ -0,0 +1,123 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type commandItemTestSuite struct {
+	suite.Suite
+}
+
+func (s *commandItemTestSuite) TestCreateCommandItems() {
+	cmds, err := createCommandItems(appConfig{})
+	s.NoError(err)
+
+	s.Equal([]commandItem{
+		{name: "serve", path: "serve", key: "serve", parent: "", desc: "Start the server"},
+		{name: "migrate", path: "migrate", key: "migrate", parent: "", desc: "Manage the database schema"},
+		{name: "up", path: "migrate up", key: "migrate.up", parent: "migrate", desc: "Apply the migrations"},
+	}, cmds)
+}
+
+func (s *commandItemTestSuite) TestCreateCommandItemsInPlainSection() {
+	cmds, err := createCommandItems(struct {
+		Tools struct {
+			Lint struct{} `cmd:"lint"`
+		}
+	}{})
+	s.NoError(err)
+
+	s.Equal([]commandItem{
+		{name: "lint", path: "lint", key: "tools.lint", parent: ""},
+	}, cmds)
+}
+
+func (s *commandItemTestSuite) TestCreateCommandItemsInNamedSection() {
+	type config struct {
+		ServerGroup struct {
+			Serve struct {
+				Port int
+			} `cmd:"serve"`
+		}
+		Admin struct {
+			Migrate struct {
+				Steps int
+			} `cmd:"migrate"`
+		} `key:"ops"`
+	}
+
+	cmds, err := createCommandItems(config{})
+	s.NoError(err)
+
+	s.Equal([]commandItem{
+		{name: "serve", path: "serve", key: "server_group.serve", parent: ""},
+		{name: "migrate", path: "migrate", key: "ops.migrate", parent: ""},
+	}, cmds)
+
+	cfgs, err := createConfigItems(config{})
+	s.NoError(err)
+
+	commands := map[string]string{}
+	for _, item := range cfgs {
+		commands[item.name] = item.command
+	}
+
+	s.Equal(map[string]string{
+		"server_group.serve.port": "server_group.serve",
+		"ops.migrate.steps":       "ops.migrate",
+	}, commands)
+
+	_, err = New[config](nil)
+	s.NoError(err)
+}
+
+func (s *commandItemTestSuite) TestCreateCommandItemsWithInvalidCommand() {
+	_, err := createCommandItems(struct {
+		Serve string `cmd:"serve"`
+	}{})
+	s.EqualError(err, "serve: command \"serve\" must be a struct")
+
+	_, err = createCommandItems(struct {
+		Serve struct{} `cmd:"serve now"`
+	}{})
+	s.EqualError(err, "serve: invalid command name \"serve now\"")
+}
+
+func (s *commandItemTestSuite) TestCreateConfigItemsWithCommands() {
+	cfgs, err := createConfigItems(appConfig{})
+	s.NoError(err)
+
+	commands := map[string]string{}
+	for _, item := range cfgs {
+		commands[item.name] = item.command
+	}
+
+	s.Equal(map[string]string{
+		"verbose":          "",
+		"serve.host":       "serve",
+		"serve.port":       "serve",
+		"serve.tls.cert":   "serve",
+		"migrate.dsn":      "migrate",
+		"migrate.up.steps": "migrate.up",
+	}, commands)
+}
+
+func TestCommandItemTestSuite(t *testing.T) {
+	suite.Run(t, new(commandItemTestSuite))
+}
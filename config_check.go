@@ -0,0 +1,195 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ConfigMode is how the config files are checked against the config struct.
+type ConfigMode int
+
+const (
+	// ConfigIgnore ignores the unknown keys of the config files, the default.
+	ConfigIgnore ConfigMode = iota
+	// ConfigLenient prints a warning for the unknown keys and the invalid values of the config files.
+	ConfigLenient
+	// ConfigStrict fails with an ErrConfig error listing the unknown keys and the invalid values of the config files.
+	ConfigStrict
+)
+
+// configProblem is an unknown key or an invalid value found in a config file.
+type configProblem struct {
+	line int
+	msg  string
+}
+
+// checkConfigFile returns the unknown keys and the invalid values of a config file,
+// sorted by line and prefixed by the file and the line.
+func (c *Cmder) checkConfigFile(file string, settings map[string]any, lines map[string]int) []string {
+	items := make(map[string]configItem)
+	c.collectItems(items)
+
+	known := make([]string, 0, len(items))
+	sections := make(map[string]bool)
+
+	for key := range items {
+		known = append(known, key)
+
+		parts := strings.Split(key, ".")
+		for i := 1; i < len(parts); i++ {
+			sections[strings.Join(parts[:i], ".")] = true
+		}
+	}
+
+	for section := range sections {
+		known = append(known, section)
+	}
+
+	var problems []configProblem
+
+	var check func(settings map[string]any, prefix string)
+	check = func(settings map[string]any, prefix string) {
+		for k, value := range settings {
+			key := prefix + k
+
+			if item, ok := items[key]; ok {
+				if s, ok := value.(string); value == nil || ok && item.isSecret && strings.HasPrefix(s, secretFileScheme) {
+					continue
+				}
+				if _, err := item.decode(value); err != nil {
+					problems = append(problems, configProblem{lines[key], fmt.Sprintf("%s: invalid value: %v", key, item.redactError(err, value))})
+				}
+				continue
+			}
+
+			if sections[key] {
+				if m, ok := value.(map[string]any); ok {
+					check(m, key+".")
+				} else {
+					problems = append(problems, configProblem{lines[key], fmt.Sprintf("%s: invalid value: expected a section", key)})
+				}
+				continue
+			}
+
+			msg := fmt.Sprintf("unknown key %q", key)
+			if suggestion := closestKey(key, known); suggestion != "" {
+				msg += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			problems = append(problems, configProblem{lines[key], msg})
+		}
+	}
+	check(settings, "")
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].line != problems[j].line {
+			return problems[i].line < problems[j].line
+		}
+		return problems[i].msg < problems[j].msg
+	})
+
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		location := file
+		if p.line > 0 {
+			location = fmt.Sprintf("%s:%d", file, p.line)
+		}
+		msgs[i] = location + ": " + p.msg
+	}
+
+	return msgs
+}
+
+// reportConfigProblems fails in strict mode and prints warnings in lenient mode.
+func (c *Cmder) reportConfigProblems(w io.Writer, problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if c.configMode == ConfigStrict {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(w, "warning: %s\n", problem)
+	}
+
+	return nil
+}
+
+// collectItems adds the config items of the Cmder and of its children by key.
+func (c *Cmder) collectItems(items map[string]configItem) {
+	for _, item := range c.items {
+		if item.arg == "" {
+			items[c.key(item)] = item
+		}
+	}
+
+	for _, child := range c.children {
+		child.collectItems(items)
+	}
+}
+
+// closestKey returns the known key closest to key, or an empty string when none is close enough.
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", len(key)/2+1
+
+	sort.Strings(known)
+
+	for _, k := range known {
+		if d := levenshtein(key, k); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
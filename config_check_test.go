@@ -0,0 +1,136 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type configCheckTestSuite struct {
+	suite.Suite
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	fs     afero.Fs
+}
+
+type checkedConfig struct {
+	Name   string
+	Labels map[string]string
+	Server struct {
+		Host string
+		Port int `default:"8080"`
+	}
+}
+
+func (s *configCheckTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.stdout.Reset()
+	s.stderr.Reset()
+}
+
+func (s *configCheckTestSuite) execute(mode ConfigMode, config string) (bool, error) {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte(config), 0644))
+
+	var run bool
+	cmder, err := New(func(_ context.Context, _ *checkedConfig) error {
+		run = true
+		return nil
+	}, WithFS(s.fs), WithConfigFile("/config.yaml"), WithConfigMode(mode))
+	s.NoError(err)
+
+	cmder.Cobra().SetOut(&s.stdout)
+	cmder.Cobra().SetErr(&s.stderr)
+	cmder.Cobra().SetArgs([]string{})
+
+	return run, cmder.Execute()
+}
+
+const invalidConfig = `name: app
+labels:
+  env: dev
+sever:
+  host: local
+server:
+  prot: 80
+  port: abc
+timeout: 5s
+`
+
+func (s *configCheckTestSuite) TestStrictMode() {
+	run, err := s.execute(ConfigStrict, invalidConfig)
+
+	s.False(run)
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, `/config.yaml:4: unknown key "sever", did you mean "server"?
+/config.yaml:7: unknown key "server.prot", did you mean "server.port"?
+/config.yaml:8: server.port: invalid value: strconv.ParseInt: parsing "abc": invalid syntax
+/config.yaml:9: unknown key "timeout"`)
+}
+
+func (s *configCheckTestSuite) TestStrictModeWithValidConfig() {
+	run, err := s.execute(ConfigStrict, "name: app\nlabels:\n  env: dev\nserver:\n  port: 80\n")
+
+	s.NoError(err)
+	s.True(run)
+}
+
+func (s *configCheckTestSuite) TestStrictModeWithSection() {
+	_, err := s.execute(ConfigStrict, "server: local\n")
+
+	s.EqualError(err, "/config.yaml:1: server: invalid value: expected a section")
+}
+
+func (s *configCheckTestSuite) TestLenientMode() {
+	run, err := s.execute(ConfigLenient, "name: app\nsever:\n  host: local\ntimeout: 5s\n")
+
+	s.NoError(err)
+	s.True(run)
+	s.Equal(`warning: /config.yaml:2: unknown key "sever", did you mean "server"?
+warning: /config.yaml:4: unknown key "timeout"
+`, s.stderr.String())
+}
+
+func (s *configCheckTestSuite) TestIgnoreMode() {
+	run, err := s.execute(ConfigIgnore, "name: app\nsever:\n  host: local\n")
+
+	s.NoError(err)
+	s.True(run)
+	s.Empty(s.stderr.String())
+}
+
+func (s *configCheckTestSuite) TestClosestKey() {
+	known := []string{"name", "server", "server.host", "server.port"}
+
+	s.Equal("server", closestKey("sever", known))
+	s.Equal("server.port", closestKey("server.prot", known))
+	s.Equal("", closestKey("timeout", known))
+	s.Equal("", closestKey("a", known))
+}
+
+func (s *configCheckTestSuite) TestLevenshtein() {
+	s.Equal(0, levenshtein("port", "port"))
+	s.Equal(1, levenshtein("sever", "server"))
+	s.Equal(2, levenshtein("prot", "port"))
+	s.Equal(4, levenshtein("", "port"))
+}
+
+func TestConfigCheckTestSuite(t *testing.T) {
+	suite.Run(t, new(configCheckTestSuite))
+}
@@ -0,0 +1,220 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const configFlagName = "config"
+
+// addConfigFlag adds the persistent --config flag used to choose the config file.
+func (c *Cmder) addConfigFlag() error {
+	if c.cobra.PersistentFlags().Lookup(configFlagName) != nil {
+		return fmt.Errorf("flag %q is reserved for the config file", configFlagName)
+	}
+
+	usage := "config file"
+	if c.envPrefix != "" {
+		usage = fmt.Sprintf("config file (env %s)", toEnvName(c.envPrefix, configFlagName))
+	}
+
+	c.cobra.PersistentFlags().String(configFlagName, "", usage)
+
+	return nil
+}
+
+// setConfigFile tells Viper which config file to read. The file given by the --config flag
+// has precedence over the <PREFIX>_CONFIG environment variable, the WithConfigFile option
+// and the WithConfigSearch paths. Without a prefix, the environment variable is not used
+// since a bare CONFIG is too likely to belong to another program.
+func (c *Cmder) setConfigFile(cmd *cobra.Command) {
+	if f := cmd.Flags().Lookup(configFlagName); f != nil && f.Changed {
+		c.viper.SetConfigFile(f.Value.String())
+		return
+	}
+
+	if file := os.Getenv(toEnvName(c.envPrefix, configFlagName)); c.envPrefix != "" && file != "" {
+		c.viper.SetConfigFile(file)
+		return
+	}
+
+	if c.configName == "" {
+		return
+	}
+
+	c.viper.SetConfigName(c.configName)
+
+	paths := c.configPaths
+	if len(paths) == 0 {
+		paths = defaultConfigPaths(c.configName)
+	}
+
+	for _, path := range paths {
+		c.viper.AddConfigPath(path)
+	}
+}
+
+// readConfig reads the config file into Viper and records the file and the line of its keys.
+// The WithConfigFiles files are merged in order with the config file on top, and the result
+// replaces the config layer of Viper so that the flags and the environment variables still
+// take precedence.
+func (c *Cmder) readConfig(cmd *cobra.Command) error {
+	c.setConfigFile(cmd)
+	c.fileSources = make(map[string]Source)
+	c.aliasSources = make(map[string]Source)
+
+	aliases := make(map[string]string)
+	c.collectAliases(aliases)
+
+	files := c.configFiles
+
+	if err := c.viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return err
+		}
+	} else {
+		files = append(files[:len(files):len(files)], c.viper.ConfigFileUsed())
+	}
+
+	appendKeys := make(map[string]bool)
+	c.collectAppendKeys(appendKeys)
+
+	merged := make(map[string]any)
+	var problems []string
+
+	for _, file := range files {
+		settings, lines, err := c.readConfigFile(file)
+		if err != nil {
+			return err
+		}
+
+		for _, alias := range renameAliases(settings, lines, aliases) {
+			c.aliasSources[alias] = Source{Kind: SourceFile, File: file, Line: lines[alias]}
+		}
+
+		if c.configMode != ConfigIgnore {
+			problems = append(problems, c.checkConfigFile(file, settings, lines)...)
+		}
+
+		c.recordFileSources(file, settings, lines, "")
+		mergeSettings(merged, settings, "", appendKeys)
+	}
+
+	if err := c.reportConfigProblems(cmd.ErrOrStderr(), problems); err != nil {
+		return err
+	}
+
+	if len(c.configFiles) == 0 && len(c.aliasSources) == 0 {
+		return nil
+	}
+
+	return c.viper.MergeConfigMap(merged)
+}
+
+// readConfigFile returns the settings of a config file and the line of its keys,
+// or nil when the file does not exist.
+func (c *Cmder) readConfigFile(file string) (map[string]any, map[string]int, error) {
+	if ok, err := afero.Exists(c.fs, file); err != nil || !ok {
+		return nil, nil, err
+	}
+
+	data, err := afero.ReadFile(c.fs, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(file), ".")
+	if !contains(viper.SupportedExts, ext) {
+		return nil, nil, fmt.Errorf("%s: %w", file, viper.UnsupportedConfigError(ext))
+	}
+
+	v := viper.New()
+	v.SetConfigType(ext)
+
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return v.AllSettings(), keyLines(file, data), nil
+}
+
+// collectAppendKeys adds the keys of the lists tagged `merge:"append"` of the Cmder and its children.
+func (c *Cmder) collectAppendKeys(keys map[string]bool) {
+	for _, item := range c.items {
+		if item.appendList {
+			keys[c.key(item)] = true
+		}
+	}
+
+	for _, child := range c.children {
+		child.collectAppendKeys(keys)
+	}
+}
+
+// mergeSettings merges src into dst. Maps are merged recursively, the other values replace
+// the existing ones except the lists of appendKeys which are appended.
+func mergeSettings(dst, src map[string]any, prefix string, appendKeys map[string]bool) {
+	for k, value := range src {
+		key := prefix + k
+
+		switch current := dst[k].(type) {
+		case map[string]any:
+			if m, ok := value.(map[string]any); ok {
+				mergeSettings(current, m, key+".", appendKeys)
+				continue
+			}
+		case []any:
+			if l, ok := value.([]any); ok && appendKeys[key] {
+				dst[k] = append(current[:len(current):len(current)], l...)
+				continue
+			}
+		}
+
+		dst[k] = value
+	}
+}
+
+// defaultConfigPaths returns the directories searched for the config file of an application,
+// in order: the working directory, $XDG_CONFIG_HOME/<app> (defaults to $HOME/.config/<app>),
+// $HOME/.<app> and /etc/<app>.
+func defaultConfigPaths(app string) []string {
+	paths := []string{"."}
+
+	home, _ := os.UserHomeDir()
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	if configHome != "" {
+		paths = append(paths, filepath.Join(configHome, app))
+	}
+
+	if home != "" {
+		paths = append(paths, filepath.Join(home, "."+app))
+	}
+
+	return append(paths, filepath.Join("/etc", app))
+}
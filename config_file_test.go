@@ -0,0 +1,248 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type configFileTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+func (s *configFileTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+
+	s.T().Setenv("HOME", "/home/user")
+	s.T().Setenv("XDG_CONFIG_HOME", "")
+}
+
+func (s *configFileTestSuite) execute(args []string, opts ...CmderOption) (int, error) {
+	var decimal int
+	cmder, err := New(func(_ context.Context, c *childConfig) error {
+		decimal = int(c.Decimal)
+		return nil
+	}, append([]CmderOption{WithFS(s.fs), WithPrefix("TEST")}, opts...)...)
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return decimal, cmder.Execute()
+}
+
+func (s *configFileTestSuite) TestDefaultConfigPaths() {
+	s.Equal([]string{".", "/home/user/.config/app", "/home/user/.app", "/etc/app"}, defaultConfigPaths("app"))
+
+	s.T().Setenv("XDG_CONFIG_HOME", "/xdg")
+	s.Equal([]string{".", "/xdg/app", "/home/user/.app", "/etc/app"}, defaultConfigPaths("app"))
+}
+
+func (s *configFileTestSuite) TestConfigFlag() {
+	s.NoError(afero.WriteFile(s.fs, "/flag.yaml", []byte("decimal: 1\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/env.yaml", []byte("decimal: 2\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/option.yaml", []byte("decimal: 3\n"), 0644))
+
+	s.T().Setenv("TEST_CONFIG", "/env.yaml")
+
+	decimal, err := s.execute([]string{"--config", "/flag.yaml"}, WithConfigFile("/option.yaml"))
+	s.NoError(err)
+	s.Equal(1, decimal)
+
+	decimal, err = s.execute([]string{}, WithConfigFile("/option.yaml"))
+	s.NoError(err)
+	s.Equal(2, decimal)
+
+	s.T().Setenv("TEST_CONFIG", "")
+
+	decimal, err = s.execute([]string{}, WithConfigFile("/option.yaml"))
+	s.NoError(err)
+	s.Equal(3, decimal)
+}
+
+func (s *configFileTestSuite) TestConfigEnvWithoutPrefix() {
+	s.NoError(afero.WriteFile(s.fs, "/option.yaml", []byte("decimal: 3\n"), 0644))
+	s.T().Setenv("CONFIG", "unrelated")
+
+	var decimal float32
+	cmder, err := New(func(_ context.Context, c *childConfig) error {
+		decimal = c.Decimal
+		return nil
+	}, WithFS(s.fs), WithConfigFile("/option.yaml"))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs([]string{})
+
+	s.NoError(cmder.Execute())
+	s.Equal(float32(3), decimal)
+
+	cmder.Cobra().SetArgs([]string{"--help"})
+	s.NoError(cmder.Execute())
+	s.Contains(s.buf.String(), "--config string     config file\n")
+}
+
+func (s *configFileTestSuite) TestConfigFlagWithMissingFile() {
+	_, err := s.execute([]string{"--config", "/missing.yaml"}, WithConfigSearch("app"))
+
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorIs(err, os.ErrNotExist)
+}
+
+func (s *configFileTestSuite) TestConfigSearch() {
+	wd, err := os.Getwd()
+	s.NoError(err)
+
+	s.NoError(afero.WriteFile(s.fs, "/etc/app/app.yaml", []byte("decimal: 4\n"), 0644))
+
+	decimal, err := s.execute([]string{}, WithConfigSearch("app"))
+	s.NoError(err)
+	s.Equal(4, decimal)
+
+	s.NoError(afero.WriteFile(s.fs, "/home/user/.app/app.json", []byte(`{"decimal": 3}`), 0644))
+
+	decimal, err = s.execute([]string{}, WithConfigSearch("app"))
+	s.NoError(err)
+	s.Equal(3, decimal)
+
+	s.NoError(afero.WriteFile(s.fs, "/home/user/.config/app/app.toml", []byte("decimal = 2\n"), 0644))
+
+	decimal, err = s.execute([]string{}, WithConfigSearch("app"))
+	s.NoError(err)
+	s.Equal(2, decimal)
+
+	s.NoError(afero.WriteFile(s.fs, filepath.Join(wd, "app.yaml"), []byte("decimal: 1\n"), 0644))
+
+	decimal, err = s.execute([]string{}, WithConfigSearch("app"))
+	s.NoError(err)
+	s.Equal(1, decimal)
+}
+
+func (s *configFileTestSuite) TestConfigSearchWithPaths() {
+	s.NoError(afero.WriteFile(s.fs, "/etc/app/app.yaml", []byte("decimal: 4\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/opt/app/app.yaml", []byte("decimal: 5\n"), 0644))
+
+	decimal, err := s.execute([]string{}, WithConfigSearch("app", "/srv", "/opt/app"))
+	s.NoError(err)
+	s.Equal(5, decimal)
+}
+
+func (s *configFileTestSuite) TestConfigSearchWithoutFile() {
+	decimal, err := s.execute([]string{}, WithConfigSearch("app"))
+	s.NoError(err)
+	s.Equal(1, decimal)
+}
+
+func (s *configFileTestSuite) TestConfigFlagIsReserved() {
+	_, err := New(func(context.Context, *struct{ Config string }) error { return nil })
+
+	s.EqualError(err, "flag \"config\" is reserved for the config file")
+}
+
+type layeredConfig struct {
+	Name  string
+	Hosts []string `merge:"append"`
+	Tags  []string
+	DB    struct {
+		Host string `default:"localhost"`
+		Port int
+	}
+}
+
+func (s *configFileTestSuite) executeLayered(args []string, opts ...CmderOption) (layeredConfig, error) {
+	var cfg layeredConfig
+	cmder, err := New(func(_ context.Context, c *layeredConfig) error {
+		cfg = *c
+		return nil
+	}, append([]CmderOption{WithFS(s.fs), WithPrefix("TEST")}, opts...)...)
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return cfg, cmder.Execute()
+}
+
+func (s *configFileTestSuite) TestConfigFiles() {
+	s.NoError(afero.WriteFile(s.fs, "/usr/share/app/base.yaml", []byte(`
+name: base
+hosts: [a, b]
+tags: [x, y]
+db:
+  host: db.local
+  port: 5432
+`), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/etc/app/site.toml", []byte(`
+hosts = ["c"]
+tags = ["z"]
+
+[db]
+port = 6432
+`), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/home/user/app.json", []byte(`{"name": "user", "hosts": ["d"]}`), 0644))
+
+	opts := []CmderOption{
+		WithConfigFiles("/usr/share/app/base.yaml", "/etc/app/site.toml", "/home/user/missing.yaml"),
+		WithConfigFile("/home/user/app.json"),
+	}
+
+	cfg, err := s.executeLayered([]string{}, opts...)
+	s.NoError(err)
+	s.Equal("user", cfg.Name)
+	s.Equal([]string{"a", "b", "c", "d"}, cfg.Hosts)
+	s.Equal([]string{"z"}, cfg.Tags)
+	s.Equal("db.local", cfg.DB.Host)
+	s.Equal(6432, cfg.DB.Port)
+
+	s.T().Setenv("TEST_DB_PORT", "7432")
+
+	cfg, err = s.executeLayered([]string{"--name", "flag"}, opts...)
+	s.NoError(err)
+	s.Equal("flag", cfg.Name)
+	s.Equal(7432, cfg.DB.Port)
+}
+
+func (s *configFileTestSuite) TestConfigFilesWithoutConfigFile() {
+	s.NoError(afero.WriteFile(s.fs, "/base.yaml", []byte("name: base\nhosts: [a]\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/site.yaml", []byte("hosts: [b]\n"), 0644))
+
+	cfg, err := s.executeLayered([]string{}, WithConfigFiles("/base.yaml", "/site.yaml"))
+	s.NoError(err)
+	s.Equal("base", cfg.Name)
+	s.Equal([]string{"a", "b"}, cfg.Hosts)
+	s.Equal("localhost", cfg.DB.Host)
+}
+
+func (s *configFileTestSuite) TestConfigFilesWithInvalidFile() {
+	s.NoError(afero.WriteFile(s.fs, "/base.yaml", []byte("hosts: [unclosed"), 0644))
+
+	_, err := s.executeLayered([]string{}, WithConfigFiles("/base.yaml"))
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorContains(err, "/base.yaml")
+}
+
+func TestConfigFileTestSuite(t *testing.T) {
+	suite.Run(t, new(configFileTestSuite))
+}
@@ -0,0 +1,426 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const configCommandName = "config"
+
+var (
+	sampleFormats = []string{"yaml", "toml", "json"}
+	bareTOMLKey   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// sampleNode is a section or a key of a sample config file, in the order of the config struct.
+type sampleNode struct {
+	name     string
+	desc     string
+	value    any
+	children []*sampleNode
+}
+
+func (n *sampleNode) isSection() bool {
+	return n.children != nil
+}
+
+// section returns the section named name, adding it when missing.
+func (n *sampleNode) section(name string) *sampleNode {
+	for _, child := range n.children {
+		if child.name == name && child.isSection() {
+			return child
+		}
+	}
+
+	child := &sampleNode{name: name, children: []*sampleNode{}}
+	n.children = append(n.children, child)
+
+	return child
+}
+
+// configCommand returns the `config` command grouping the config file commands, adding it when missing.
+// The config commands don't use the config values: the config file isn't read and the required flags
+// aren't enforced.
+func (c *Cmder) configCommand() (*cobra.Command, error) {
+	for _, cmd := range c.cobra.Commands() {
+		if cmd.Name() == configCommandName {
+			if cmd.Annotations[configCommandName] != "true" {
+				return nil, fmt.Errorf("command %q is reserved for the config file commands", configCommandName)
+			}
+			return cmd, nil
+		}
+	}
+
+	cmd := &cobra.Command{
+		Use:         configCommandName,
+		Short:       "Manage the config file",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{configCommandName: "true"},
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			c.root().parsed = true
+			return nil
+		},
+	}
+
+	// Cobra enforces the required flags after the hooks, so the required flags of the root are
+	// shadowed by hidden copies without the annotation. The copies share the value of the flag.
+	c.cobra.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok {
+			shadow := *f
+			shadow.Annotations = nil
+			shadow.Hidden = true
+			if secret, ok := f.Annotations[secretAnnotation]; ok {
+				shadow.Annotations = map[string][]string{secretAnnotation: secret}
+			}
+			cmd.PersistentFlags().AddFlag(&shadow)
+		}
+	})
+
+	c.cobra.AddCommand(cmd)
+
+	return cmd, nil
+}
+
+// addConfigInitCommand adds the `config init [file]` command writing a sample config file.
+func (c *Cmder) addConfigInitCommand() error {
+	parent, err := c.configCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd := &cobra.Command{
+		Use:   "init [file]",
+		Short: "Write a sample config file with the defaults",
+		Long: "Write a sample config file with every key, its default value and its description.\n" +
+			"Without a file, the sample is written to the standard output.",
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	format := cmd.Flags().String("format", "", fmt.Sprintf("format of the file, one of %s (default from the file extension or yaml)", strings.Join(sampleFormats, ", ")))
+	force := cmd.Flags().Bool("force", false, "overwrite an existing file")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		file := ""
+		if len(args) > 0 {
+			file = args[0]
+		}
+
+		f, err := sampleFormat(*format, file)
+		if err != nil {
+			return &cmderError{kind: ErrUsage, err: err}
+		}
+
+		sample, err := c.SampleConfig(f)
+		if err != nil {
+			return err
+		}
+
+		if file == "" {
+			_, err = cmd.OutOrStdout().Write(sample)
+			return err
+		}
+
+		return c.writeSampleConfig(file, sample, *force)
+	}
+
+	parent.AddCommand(cmd)
+
+	return nil
+}
+
+// sampleFormat returns the format of the sample config file. It defaults to the file
+// extension when it is toml or json, otherwise to yaml.
+func sampleFormat(format, file string) (string, error) {
+	if format == "" {
+		switch ext := strings.TrimPrefix(filepath.Ext(file), "."); ext {
+		case "toml", "json":
+			return ext, nil
+		default:
+			return "yaml", nil
+		}
+	}
+
+	for _, f := range sampleFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid format %q, must be one of %s", format, strings.Join(sampleFormats, ", "))
+}
+
+func (c *Cmder) writeSampleConfig(file string, sample []byte, force bool) error {
+	if !force {
+		if exists, err := afero.Exists(c.fs, file); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", file)
+		}
+	}
+
+	if err := c.fs.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(c.fs, file, sample, 0644)
+}
+
+// SampleConfig returns a sample config file in the yaml, toml or json format with every key
+// and its default value. The descriptions are added as comments, except in json. The secret
+// values are left empty and the optional keys without a default are null, or commented out in toml.
+func (c *Cmder) SampleConfig(format string) ([]byte, error) {
+	root := &sampleNode{children: []*sampleNode{}}
+	c.addSampleNodes(root)
+
+	switch format {
+	case "yaml":
+		return yamlSample(root)
+	case "toml":
+		return tomlSample(root)
+	case "json":
+		return jsonSample(root)
+	}
+
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// addSampleNodes adds the keys of the Cmder and of its children to the sample.
+func (c *Cmder) addSampleNodes(root *sampleNode) {
+	for _, item := range c.items {
+		if item.arg != "" {
+			continue
+		}
+
+		parts := strings.Split(c.key(item), ".")
+
+		section := root
+		for _, part := range parts[:len(parts)-1] {
+			section = section.section(part)
+		}
+
+		node := &sampleNode{name: parts[len(parts)-1], desc: item.desc}
+
+		switch {
+		case item.isSecret:
+			node.value = item.printValue(reflect.Zero(item.typ))
+		case item.optional && !item.hasDefaultValue:
+		default:
+			node.value = item.printValue(reflect.ValueOf(item.defaultValue))
+		}
+
+		section.children = append(section.children, node)
+	}
+
+	for _, child := range c.children {
+		child.addSampleNodes(root)
+	}
+}
+
+func yamlSample(root *sampleNode) ([]byte, error) {
+	node, err := yamlSampleNode(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func yamlSampleNode(n *sampleNode) (*yaml.Node, error) {
+	if !n.isSection() {
+		var value yaml.Node
+		err := value.Encode(n.value)
+		return &value, err
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, child := range n.children {
+		value, err := yamlSampleNode(child)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: child.name, HeadComment: child.desc}
+		mapping.Content = append(mapping.Content, key, value)
+	}
+
+	return mapping, nil
+}
+
+func tomlSample(root *sampleNode) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := writeTOMLSection(&buf, root, ""); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimLeft(buf.Bytes(), "\n"), nil
+}
+
+func writeTOMLSection(buf *bytes.Buffer, n *sampleNode, table string) error {
+	if table != "" {
+		fmt.Fprintf(buf, "\n[%s]\n", table)
+	}
+
+	for _, child := range n.children {
+		if child.isSection() {
+			continue
+		}
+
+		if child.desc != "" {
+			fmt.Fprintf(buf, "# %s\n", child.desc)
+		}
+
+		if child.value == nil {
+			fmt.Fprintf(buf, "# %s =\n", tomlKeyName(child.name))
+			continue
+		}
+
+		value, err := tomlValue(child.value)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(buf, "%s = %s\n", tomlKeyName(child.name), value)
+	}
+
+	for _, child := range n.children {
+		if !child.isSection() {
+			continue
+		}
+
+		name := tomlKeyName(child.name)
+		if table != "" {
+			name = table + "." + name
+		}
+
+		if err := writeTOMLSection(buf, child, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tomlKeyName quotes a toml key unless it is a bare key.
+func tomlKeyName(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+
+	return strconv.Quote(key)
+}
+
+// tomlValue returns the toml form of a value, maps are written as inline tables.
+func tomlValue(value any) (string, error) {
+	if m, ok := value.(map[string]any); ok {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		entries := make([]string, len(keys))
+		for i, k := range keys {
+			v, err := tomlValue(m[k])
+			if err != nil {
+				return "", err
+			}
+			entries[i] = tomlKeyName(k) + " = " + v
+		}
+
+		return "{" + strings.Join(entries, ", ") + "}", nil
+	}
+
+	out, err := toml.Marshal(map[string]any{"v": value})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(string(out), "v = ")), nil
+}
+
+func jsonSample(root *sampleNode) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := writeJSONNode(&buf, root, ""); err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func writeJSONNode(buf *bytes.Buffer, n *sampleNode, indent string) error {
+	if !n.isSection() {
+		out, err := json.Marshal(n.value)
+		buf.Write(out)
+		return err
+	}
+
+	if len(n.children) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+
+	buf.WriteString("{\n")
+
+	for i, child := range n.children {
+		key, _ := json.Marshal(child.name)
+		fmt.Fprintf(buf, "%s  %s: ", indent, key)
+
+		if err := writeJSONNode(buf, child, indent+"  "); err != nil {
+			return err
+		}
+
+		if i < len(n.children)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+
+	buf.WriteString(indent + "}")
+
+	return nil
+}
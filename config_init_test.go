@@ -0,0 +1,194 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type configInitTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type sampleConfig struct {
+	Name    string        `desc:"Name of the application" default:"app" required:"true"`
+	Token   string        `desc:"API token" secret:"true" default:"s3cr3t"`
+	Timeout time.Duration `desc:"Request timeout" default:"5s"`
+	Retries *int          `desc:"Number of retries"`
+	Hosts   []string      `default:"a,b"`
+	Labels  map[string]string
+	Server  struct {
+		Port int    `desc:"Listening port" default:"8080"`
+		URL  string `desc:"Public url"`
+	}
+}
+
+func (s *configInitTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+}
+
+func (s *configInitTestSuite) execute(args ...string) (bool, error) {
+	var run bool
+	cmder, err := New(func(_ context.Context, _ *sampleConfig) error {
+		run = true
+		return nil
+	}, WithFS(s.fs), WithConfigInit())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return run, cmder.Execute()
+}
+
+func (s *configInitTestSuite) TestConfigInitYAML() {
+	run, err := s.execute("config", "init")
+	s.NoError(err)
+	s.False(run)
+
+	s.Equal(`# Name of the application
+name: app
+# API token
+token: ""
+# Request timeout
+timeout: 5s
+# Number of retries
+retries: null
+hosts:
+  - a
+  - b
+labels: {}
+server:
+  # Listening port
+  port: 8080
+  # Public url
+  url: ""
+`, s.buf.String())
+}
+
+func (s *configInitTestSuite) TestConfigInitTOML() {
+	_, err := s.execute("config", "init", "--format", "toml")
+	s.NoError(err)
+
+	s.Equal(`# Name of the application
+name = 'app'
+# API token
+token = ''
+# Request timeout
+timeout = '5s'
+# Number of retries
+# retries =
+hosts = ['a', 'b']
+labels = {}
+
+[server]
+# Listening port
+port = 8080
+# Public url
+url = ''
+`, s.buf.String())
+}
+
+func (s *configInitTestSuite) TestConfigInitJSON() {
+	_, err := s.execute("config", "init", "--format", "json")
+	s.NoError(err)
+
+	s.Equal(`{
+  "name": "app",
+  "token": "",
+  "timeout": "5s",
+  "retries": null,
+  "hosts": ["a","b"],
+  "labels": {},
+  "server": {
+    "port": 8080,
+    "url": ""
+  }
+}
+`, s.buf.String())
+}
+
+func (s *configInitTestSuite) TestConfigInitWritesFile() {
+	_, err := s.execute("config", "init", "/home/user/.config/app/app.toml")
+	s.NoError(err)
+
+	data, err := afero.ReadFile(s.fs, "/home/user/.config/app/app.toml")
+	s.NoError(err)
+	s.Contains(string(data), "name = 'app'\n")
+
+	_, err = s.execute("config", "init", "/home/user/.config/app/app.toml")
+	s.EqualError(err, "/home/user/.config/app/app.toml already exists, use --force to overwrite it")
+
+	_, err = s.execute("config", "init", "/home/user/.config/app/app.toml", "--force", "--format", "yaml")
+	s.NoError(err)
+
+	data, err = afero.ReadFile(s.fs, "/home/user/.config/app/app.toml")
+	s.NoError(err)
+	s.Contains(string(data), "name: app\n")
+}
+
+func (s *configInitTestSuite) TestConfigInitWithInvalidFormat() {
+	_, err := s.execute("config", "init", "--format", "xml")
+
+	s.ErrorIs(err, ErrUsage)
+	s.EqualError(err, `invalid format "xml", must be one of yaml, toml, json`)
+}
+
+func (s *configInitTestSuite) TestConfigInitKeepsRequiredFlags() {
+	cmder, err := New(func(context.Context, *sampleConfig) error { return nil }, WithFS(s.fs), WithConfigInit())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+
+	cmder.Cobra().SetArgs([]string{"config", "init"})
+	s.NoError(cmder.Execute())
+
+	cmder.Cobra().SetArgs([]string{})
+	err = cmder.Execute()
+
+	s.EqualError(err, "required flag(s) \"name\" not set")
+	s.Equal(ExitUsage, cmder.ExitCode(err))
+}
+
+func (s *configInitTestSuite) TestSampleFormat() {
+	for file, expected := range map[string]string{"": "yaml", "app.yml": "yaml", "app.conf": "yaml", "app.toml": "toml", "app.json": "json"} {
+		format, err := sampleFormat("", file)
+		s.NoError(err)
+		s.Equal(expected, format, file)
+	}
+}
+
+func (s *configInitTestSuite) TestConfigCommandIsReserved() {
+	_, err := New(func(_ context.Context, _ *struct {
+		Config struct{} `cmd:"config"`
+	}) error {
+		return nil
+	}, WithConfigInit())
+
+	s.EqualError(err, `command "config" is reserved for the config file commands`)
+}
+
+func TestConfigInitTestSuite(t *testing.T) {
+	suite.Run(t, new(configInitTestSuite))
+}
@@ -15,9 +15,15 @@
 package gocmder
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cast"
 )
 
 const (
@@ -25,59 +31,546 @@ const (
 	defaultValueKey = "default"
 	isHiddenKey     = "hidden"
 	isRequiredKey   = "required"
+	layoutKey       = "layout"
+	sepKey          = "sep"
+	mergeKey        = "merge"
+	secretKey       = "secret"
+	flagNameKey     = "flag"
+	envNameKey      = "env"
+	configKeyKey    = "key"
+	shortKey        = "short"
+
+	mergeAppend = "append"
+
+	defaultSep = ","
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
 )
 
 type configItem struct {
 	name            string
 	kind            reflect.Kind
+	typ             reflect.Type
+	index           []int
 	desc            string
 	defaultValue    any
 	hasDefaultValue bool
 	isHidden        bool
 	isRequired      bool
+	layout          string
+	sep             string
+	elem            *configItem
+	parser          parseFunc
+	optional        bool
+	command         string
+	arg             string
+	appendList      bool
+	isSecret        bool
+	rules           validationRules
+	flag            string
+	short           string
+	env             []string
+	deprecated      string
+	aliases         []string
+	// legacyKey is the key made of the lowercase field names used before the snake_case keys.
+	legacyKey string
 }
 
-func newConfigItem(name string, sf reflect.StructField) configItem {
+// kindTypes maps the supported kinds to the type used for their flag and default value.
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.String:  reflect.TypeOf(""),
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+}
+
+func newConfigItem(name string, index []int, sf reflect.StructField) (configItem, error) {
 	value, hasDefault := sf.Tag.Lookup(defaultValueKey)
 
-	kind := sf.Type.Kind()
+	isHidden, _ := strconv.ParseBool(sf.Tag.Get(isHiddenKey))
+	isRequired, _ := strconv.ParseBool(sf.Tag.Get(isRequiredKey))
+	isSecret, _ := strconv.ParseBool(sf.Tag.Get(secretKey))
 
-	var defaultValue any = value
+	item := configItem{
+		name:            name,
+		kind:            sf.Type.Kind(),
+		index:           index,
+		desc:            sf.Tag.Get(descKey),
+		hasDefaultValue: hasDefault,
+		isHidden:        isHidden,
+		isRequired:      isRequired,
+		isSecret:        isSecret,
+		layout:          sf.Tag.Get(layoutKey),
+		arg:             sf.Tag.Get(argKey),
+		flag:            sf.Tag.Get(flagNameKey),
+		short:           sf.Tag.Get(shortKey),
+	}
 
-	if kind == reflect.Int {
-		defaultValue, _ = strconv.Atoi(value)
+	if item.short != "" && (len(item.short) != 1 || !unicode.IsLetter(rune(item.short[0])) && !unicode.IsDigit(rune(item.short[0]))) {
+		return configItem{}, fmt.Errorf("%s: invalid short %q, expected a letter or a digit", name, item.short)
 	}
 
-	if kind == reflect.Bool {
-		defaultValue, _ = strconv.ParseBool(value)
+	if deprecated, ok := sf.Tag.Lookup(deprecatedKey); ok {
+		if deprecated == "" {
+			return configItem{}, fmt.Errorf("%s: deprecated requires a message", name)
+		}
+		item.deprecated = deprecated
 	}
 
-	if kind == reflect.Float32 {
-		defaultValue, _ = strconv.ParseFloat(value, 32)
-		defaultValue = float32(defaultValue.(float64))
+	for _, alias := range strings.Split(sf.Tag.Get(aliasesKey), ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			item.aliases = append(item.aliases, strings.ToLower(alias))
+		}
 	}
 
-	isHidden, _ := strconv.ParseBool(sf.Tag.Get(isHiddenKey))
-	isRequired, _ := strconv.ParseBool(sf.Tag.Get(isRequiredKey))
+	for _, env := range strings.Split(sf.Tag.Get(envNameKey), ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			item.env = append(item.env, env)
+		}
+	}
 
-	return configItem{
-		name:            name,
-		kind:            kind,
-		desc:            sf.Tag.Get(descKey),
-		defaultValue:    defaultValue,
-		hasDefaultValue: hasDefault,
-		isHidden:        isHidden,
-		isRequired:      isRequired,
+	if err := item.resolveType(sf.Type); err != nil {
+		return configItem{}, err
+	}
+
+	if item.elem != nil {
+		item.elem.isSecret = isSecret
+		item.sep = defaultSep
+		if sep, ok := sf.Tag.Lookup(sepKey); ok && sep != "" {
+			item.sep = sep
+		}
+	}
+
+	switch merge := sf.Tag.Get(mergeKey); merge {
+	case "", "replace":
+	case mergeAppend:
+		if item.typ.Kind() != reflect.Slice {
+			return configItem{}, fmt.Errorf("%s: merge %q requires a slice", name, merge)
+		}
+		item.appendList = true
+	default:
+		return configItem{}, fmt.Errorf("%s: invalid merge strategy %q", name, merge)
+	}
+
+	var err error
+	if item.rules, err = newValidationRules(item, sf); err != nil {
+		return configItem{}, err
+	}
+
+	item.defaultValue = reflect.Zero(item.typ).Interface()
+
+	if hasDefault {
+		var err error
+		if item.defaultValue, err = item.parse(value); err != nil {
+			return configItem{}, fmt.Errorf("%s: invalid default value: %w", name, err)
+		}
+	}
+
+	return item, nil
+}
+
+// localName returns the name of the item relative to its command section.
+func (item configItem) localName() string {
+	if item.command == "" {
+		return item.name
+	}
+
+	return strings.TrimPrefix(item.name, item.command+".")
+}
+
+// flagName returns the name of the flag of the item, from its `flag` tag or from its key.
+func (item configItem) flagName() string {
+	if item.flag != "" {
+		return item.flag
+	}
+
+	return toFlagName(item.localName())
+}
+
+// resolveType sets the type used for the flag and the default value of the item.
+// Slices and maps with string keys get an element item used to parse their values.
+func (item *configItem) resolveType(t reflect.Type) error {
+	parser, isText := textParser(t)
+
+	switch {
+	case t == durationType:
+		item.typ = t
+	case t == timeType:
+		item.typ = t
+		if item.layout == "" {
+			item.layout = time.RFC3339
+		}
+	case isText:
+		item.typ = t
+		item.parser = parser
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() != reflect.Pointer:
+		item.kind = t.Elem().Kind()
+		item.optional = true
+		return item.resolveType(t.Elem())
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		elem := configItem{name: item.name, kind: t.Elem().Kind(), layout: item.layout}
+		if err := elem.resolveType(t.Elem()); err != nil {
+			return err
+		}
+
+		if elem.elem != nil || elem.optional {
+			return fmt.Errorf("%s: unsupported type %s", item.name, t)
+		}
+
+		item.typ = t
+		item.elem = &elem
+		item.layout = elem.layout
+	default:
+		typ, ok := kindTypes[t.Kind()]
+		if !ok {
+			return fmt.Errorf("%s: unsupported type %s", item.name, t.Kind())
+		}
+		item.typ = typ
+	}
+
+	return nil
+}
+
+// parse parses a string from a tag, a flag or an environment variable into
+// a value of the item type. Slices and maps are separated by the item separator,
+// with map entries written as key=value.
+func (item configItem) parse(value string) (any, error) {
+	if item.parser != nil {
+		if value == "" {
+			return reflect.Zero(item.typ).Interface(), nil
+		}
+		return item.parser(value)
+	}
+
+	switch item.kind {
+	case reflect.Slice:
+		values := splitList(value, item.sep)
+		elems := make([]any, len(values))
+		for i, v := range values {
+			elems[i] = v
+		}
+		return item.decodeSlice(elems)
+	case reflect.Map:
+		entries := make(map[string]any)
+		for _, entry := range splitList(value, item.sep) {
+			k, v, ok := strings.Cut(entry, "=")
+			if !ok {
+				return nil, item.redactError(fmt.Errorf("%q must be formatted as key=value", entry), entry)
+			}
+			entries[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		return item.decodeMap(entries)
 	}
+
+	switch item.typ {
+	case durationType:
+		return time.ParseDuration(value)
+	case timeType:
+		if value == "" {
+			return time.Time{}, nil
+		}
+		return time.Parse(item.layout, value)
+	}
+
+	return parseValue(item.typ, value)
+}
+
+// decode converts a raw value returned by viper into a value of the item type.
+// Config files may already hold typed values, everything else is parsed from its string form.
+func (item configItem) decode(raw any) (any, error) {
+	if t, ok := raw.(time.Time); ok && item.typ == timeType {
+		return t, nil
+	}
+
+	if reflect.TypeOf(raw) == item.typ && item.parser != nil {
+		return raw, nil
+	}
+
+	if item.elem != nil {
+		if rv := reflect.ValueOf(raw); rv.Kind() == reflect.Slice && item.kind == reflect.Slice {
+			values := make([]any, rv.Len())
+			for i := range values {
+				values[i] = rv.Index(i).Interface()
+			}
+			return item.decodeSlice(values)
+		}
+
+		if m, err := cast.ToStringMapE(raw); err == nil && item.kind == reflect.Map {
+			return item.decodeMap(m)
+		}
+	}
+
+	value, err := cast.ToStringE(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.parse(value)
 }
 
-func createConfigItems(cfg any) []configItem {
+func (item configItem) decodeSlice(values []any) (any, error) {
+	slice := reflect.MakeSlice(item.typ, 0, len(values))
+
+	for i, raw := range values {
+		value, err := item.elem.decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, item.redactError(err, raw))
+		}
+		slice = reflect.Append(slice, reflect.ValueOf(value).Convert(item.typ.Elem()))
+	}
+
+	return slice.Interface(), nil
+}
+
+func (item configItem) decodeMap(values map[string]any) (any, error) {
+	m := reflect.MakeMapWithSize(item.typ, len(values))
+
+	for k, raw := range values {
+		value, err := item.elem.decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k, item.redactError(err, raw))
+		}
+		m.SetMapIndex(reflect.ValueOf(k).Convert(item.typ.Key()), reflect.ValueOf(value).Convert(item.typ.Elem()))
+	}
+
+	return m.Interface(), nil
+}
+
+// formatValues returns the string form of every element of a slice item value.
+func (item configItem) formatValues(value any) []string {
+	rv := reflect.ValueOf(value)
+	values := make([]string, rv.Len())
+
+	for i := range values {
+		values[i] = item.elem.formatValue(rv.Index(i).Interface())
+	}
+
+	return values
+}
+
+// formatEntries returns the string form of every entry of a map item value.
+func (item configItem) formatEntries(value any) map[string]string {
+	rv := reflect.ValueOf(value)
+	entries := make(map[string]string, rv.Len())
+
+	iter := rv.MapRange()
+	for iter.Next() {
+		entries[iter.Key().String()] = item.elem.formatValue(iter.Value().Interface())
+	}
+
+	return entries
+}
+
+// formatValue returns the string form of a value of the item type, as accepted by parse.
+func (item configItem) formatValue(value any) string {
+	if item.parser != nil {
+		if reflect.ValueOf(value).IsZero() {
+			return ""
+		}
+		return formatText(value)
+	}
+
+	switch item.kind {
+	case reflect.Slice:
+		return strings.Join(item.formatValues(value), item.sep)
+	case reflect.Map:
+		entries := item.formatEntries(value)
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			keys[i] = k + "=" + entries[k]
+		}
+		return strings.Join(keys, item.sep)
+	}
+
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(item.layout)
+	}
+
+	return cast.ToString(value)
+}
+
+// parseValue parses a string into a value of the given basic type.
+// Integers and floats are parsed with the bit size of the type so that
+// out of range values are reported instead of being truncated.
+func parseValue(typ reflect.Type, value string) (any, error) {
+	v := reflect.New(typ).Elem()
+
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 0, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetFloat(f)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ.Kind())
+	}
+
+	return v.Interface(), nil
+}
+
+// isSection reports whether a struct field is a nested config section
+// rather than a single value such as a time.Time or a text type.
+func isSection(t reflect.Type) bool {
+	if _, isText := textParser(t); isText {
+		return false
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return isSection(t.Elem())
+	}
+
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// splitList splits a separated list, trimming the spaces around each element.
+// An empty string is an empty list.
+func splitList(value, sep string) []string {
+	if strings.TrimSpace(value) == "" {
+		return []string{}
+	}
+
+	values := strings.Split(value, sep)
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+
+	return values
+}
+
+// section is the position of a nested struct in the config.
+type section struct {
+	// prefix is the key of the section followed by a dot.
+	prefix string
+	// index is the index sequence of the section field.
+	index []int
+	// optional is true under a pointer to a struct so that the section is only allocated when set.
+	optional bool
+	// command is the key of the command section owning the items, empty for the root command.
+	command string
+	// legacy is the prefix made of the lowercase field names used before the snake_case keys.
+	legacy string
+}
+
+// child returns the section of a nested struct field. A field tagged with `cmd`
+// is the section of a subcommand.
+func (sec section) child(vf reflect.StructField) section {
+	child := section{
+		prefix:   sec.prefix + fieldKey(vf) + ".",
+		index:    append(append([]int{}, sec.index...), vf.Index...),
+		optional: sec.optional,
+		command:  sec.command,
+		legacy:   sec.legacy + strings.ToLower(vf.Name) + ".",
+	}
+
+	if cmd, ok := vf.Tag.Lookup(cmdKey); ok {
+		child.prefix = sec.prefix + cmd + "."
+		child.command = sec.prefix + cmd
+		child.legacy = sec.legacy + cmd + "."
+	}
+
+	return child
+}
+
+// keyTags are the tags naming the key of a field, by precedence.
+var keyTags = []string{configKeyKey, "mapstructure", "yaml", "json"}
+
+// fieldKey returns the key of a field relative to its section, from its tags
+// or from its name in snake_case.
+func fieldKey(vf reflect.StructField) string {
+	for _, tag := range keyTags {
+		name, _, _ := strings.Cut(vf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return strings.ToLower(name)
+		}
+	}
+
+	return toSnakeCase(vf.Name)
+}
+
+// isSkipped reports whether a field is left out of the config by its first naming
+// tag, e.g. `yaml:"-"`.
+func isSkipped(vf reflect.StructField) bool {
+	for _, tag := range keyTags {
+		name, _, _ := strings.Cut(vf.Tag.Get(tag), ",")
+		if name != "" {
+			return name == "-"
+		}
+	}
+
+	return false
+}
+
+// toSnakeCase converts a CamelCase name to snake_case, keeping the acronyms
+// together, e.g. ServerURL to server_url and HTTPPort to http_port.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+func createConfigItems(cfg any) ([]configItem, error) {
 	configItems := make([]configItem, 0)
-	recursivelyExtractConfigItems(cfg, "", &configItems)
-	return configItems
+	if err := recursivelyExtractConfigItems(cfg, section{}, &configItems); err != nil {
+		return nil, err
+	}
+	addLegacyAliases(configItems)
+	return configItems, nil
 }
 
-func recursivelyExtractConfigItems(cfg any, prefix string, cfgItems *[]configItem) {
+func recursivelyExtractConfigItems(cfg any, sec section, cfgItems *[]configItem) error {
 	cfgType := reflect.TypeOf(cfg)
 
 	if cfgType.Name() == "StructField" {
@@ -85,13 +578,39 @@ func recursivelyExtractConfigItems(cfg any, prefix string, cfgItems *[]configIte
 		cfgType = sf.Type
 	}
 
+	if cfgType.Kind() == reflect.Pointer {
+		cfgType = cfgType.Elem()
+		sec.optional = true
+	}
+
 	for _, vf := range reflect.VisibleFields(cfgType) {
-		name := strings.ToLower(vf.Name)
+		if isSkipped(vf) {
+			continue
+		}
+
+		if isSection(vf.Type) {
+			if err := recursivelyExtractConfigItems(vf, sec.child(vf), cfgItems); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, sec.index...), vf.Index...)
 
-		if vf.Type.Kind() == reflect.Struct {
-			recursivelyExtractConfigItems(vf, prefix+name+".", cfgItems)
-		} else {
-			*cfgItems = append(*cfgItems, newConfigItem(prefix+name, vf))
+		item, err := newConfigItem(sec.prefix+fieldKey(vf), fieldIndex, vf)
+		if err != nil {
+			return err
 		}
+
+		item.optional = item.optional || sec.optional
+		item.command = sec.command
+
+		if legacyKey := sec.legacy + strings.ToLower(vf.Name); legacyKey != item.name && item.arg == "" {
+			item.legacyKey = legacyKey
+		}
+
+		*cfgItems = append(*cfgItems, item)
 	}
+
+	return nil
 }
@@ -17,6 +17,7 @@ package gocmder
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -26,7 +27,8 @@ type configItemTestSuite struct {
 }
 
 func (s *configItemTestSuite) TestCreateConfigItems() {
-	cfgs := createConfigItems(configTest{})
+	cfgs, err := createConfigItems(configTest{})
+	s.NoError(err)
 
 	s.Equal(8, len(cfgs))
 
@@ -35,6 +37,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "foo",
 				kind:            reflect.String,
+				typ:             reflect.TypeOf(""),
+				index:           []int{0},
 				desc:            "foo",
 				defaultValue:    any("foo"),
 				hasDefaultValue: true,
@@ -44,6 +48,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "bar",
 				kind:            reflect.String,
+				typ:             reflect.TypeOf(""),
+				index:           []int{1},
 				desc:            "bar",
 				defaultValue:    any("bar"),
 				hasDefaultValue: true,
@@ -53,6 +59,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "sc.foostring",
 				kind:            reflect.String,
+				typ:             reflect.TypeOf(""),
+				index:           []int{2, 0},
 				desc:            "foostring",
 				defaultValue:    any("foostring"),
 				hasDefaultValue: true,
@@ -62,6 +70,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "sc.barstring",
 				kind:            reflect.String,
+				typ:             reflect.TypeOf(""),
+				index:           []int{2, 1},
 				desc:            "barstring",
 				defaultValue:    any(""),
 				hasDefaultValue: false,
@@ -71,6 +81,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "sc.ic.fooint",
 				kind:            reflect.Int,
+				typ:             reflect.TypeOf(0),
+				index:           []int{2, 2, 0},
 				desc:            "fooint",
 				defaultValue:    any(1),
 				hasDefaultValue: true,
@@ -80,6 +92,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "sc.ic.barint",
 				kind:            reflect.Int,
+				typ:             reflect.TypeOf(0),
+				index:           []int{2, 2, 1},
 				desc:            "barint",
 				defaultValue:    any(0),
 				hasDefaultValue: false,
@@ -89,6 +103,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "sc.ic.bc.foobool",
 				kind:            reflect.Bool,
+				typ:             reflect.TypeOf(false),
+				index:           []int{2, 2, 2, 0},
 				desc:            "foobool",
 				defaultValue:    any(true),
 				hasDefaultValue: true,
@@ -98,6 +114,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 			{
 				name:            "sc.ic.bc.barbool",
 				kind:            reflect.Bool,
+				typ:             reflect.TypeOf(false),
+				index:           []int{2, 2, 2, 1},
 				desc:            "barbool",
 				defaultValue:    any(false),
 				hasDefaultValue: false,
@@ -110,7 +128,8 @@ func (s *configItemTestSuite) TestCreateConfigItems() {
 }
 
 func (s *configItemTestSuite) TestCreateConfigItemsWithFloat() {
-	cfgs := createConfigItems(floatConfigTest{})
+	cfgs, err := createConfigItems(floatConfigTest{})
+	s.NoError(err)
 	s.Equal(1, len(cfgs))
 
 	item := cfgs[0]
@@ -124,6 +143,174 @@ func (s *configItemTestSuite) TestCreateConfigItemsWithFloat() {
 	s.True(item.hasDefaultValue)
 }
 
+func (s *configItemTestSuite) TestCreateConfigItemsWithScalarKinds() {
+	cfgs, err := createConfigItems(scalarConfigTest{})
+	s.NoError(err)
+
+	defaults := map[string]any{}
+	for _, item := range cfgs {
+		defaults[item.name] = item.defaultValue
+	}
+
+	s.Equal(map[string]any{
+		"i8":  int8(-8),
+		"i16": int16(16),
+		"i32": int32(32),
+		"i64": int64(0x40),
+		"u":   uint(1),
+		"u8":  uint8(255),
+		"u16": uint16(16),
+		"u32": uint32(32),
+		"u64": uint64(64),
+		"f64": float64(6.4),
+		"nod": uint16(0),
+	}, defaults)
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithOutOfRangeDefault() {
+	_, err := createConfigItems(struct {
+		Small uint8 `default:"256"`
+	}{})
+
+	s.EqualError(err, "small: invalid default value: strconv.ParseUint: parsing \"256\": value out of range")
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithUnsupportedType() {
+	_, err := createConfigItems(struct {
+		Ch chan int
+	}{})
+
+	s.EqualError(err, "ch: unsupported type chan")
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithTime() {
+	cfgs, err := createConfigItems(struct {
+		Timeout time.Duration `default:"1m30s"`
+		Day     time.Time     `default:"2023-01-02" layout:"2006-01-02"`
+		At      time.Time
+	}{})
+	s.NoError(err)
+	s.Equal(3, len(cfgs))
+
+	s.Equal(durationType, cfgs[0].typ)
+	s.Equal(90*time.Second, cfgs[0].defaultValue)
+
+	s.Equal(timeType, cfgs[1].typ)
+	s.Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), cfgs[1].defaultValue)
+	s.Equal("2023-01-02", cfgs[1].formatValue(cfgs[1].defaultValue))
+
+	s.Equal(time.RFC3339, cfgs[2].layout)
+	s.Equal(time.Time{}, cfgs[2].defaultValue)
+	s.Equal("", cfgs[2].formatValue(cfgs[2].defaultValue))
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithInvalidDuration() {
+	_, err := createConfigItems(struct {
+		Timeout time.Duration `default:"soon"`
+	}{})
+
+	s.EqualError(err, "timeout: invalid default value: time: invalid duration \"soon\"")
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithCollections() {
+	cfgs, err := createConfigItems(struct {
+		Tags   []string          `default:"a, b"`
+		Ports  []int             `default:"80|443" sep:"|"`
+		Labels map[string]string `default:"env=dev,team=core"`
+		Empty  []string
+	}{})
+	s.NoError(err)
+	s.Equal(4, len(cfgs))
+
+	s.Equal([]string{"a", "b"}, cfgs[0].defaultValue)
+	s.Equal(",", cfgs[0].sep)
+	s.Equal("a,b", cfgs[0].formatValue(cfgs[0].defaultValue))
+
+	s.Equal([]int{80, 443}, cfgs[1].defaultValue)
+	s.Equal("80|443", cfgs[1].formatValue(cfgs[1].defaultValue))
+
+	s.Equal(map[string]string{"env": "dev", "team": "core"}, cfgs[2].defaultValue)
+	s.Equal("env=dev,team=core", cfgs[2].formatValue(cfgs[2].defaultValue))
+
+	s.Nil(cfgs[3].defaultValue)
+	s.False(cfgs[3].hasDefaultValue)
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithInvalidCollections() {
+	_, err := createConfigItems(struct {
+		Labels map[string]string `default:"env"`
+	}{})
+	s.EqualError(err, "labels: invalid default value: \"env\" must be formatted as key=value")
+
+	_, err = createConfigItems(struct {
+		Matrix [][]int
+	}{})
+	s.EqualError(err, "matrix: unsupported type [][]int")
+
+	_, err = createConfigItems(struct {
+		ByID map[int]string
+	}{})
+	s.EqualError(err, "by_id: unsupported type map")
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithMergeStrategy() {
+	cfgs, err := createConfigItems(struct {
+		Hosts []string `merge:"append"`
+		Tags  []string `merge:"replace"`
+	}{})
+	s.NoError(err)
+	s.True(cfgs[0].appendList)
+	s.False(cfgs[1].appendList)
+
+	_, err = createConfigItems(struct {
+		Labels map[string]string `merge:"append"`
+	}{})
+	s.EqualError(err, "labels: merge \"append\" requires a slice")
+
+	_, err = createConfigItems(struct {
+		Hosts []string `merge:"prepend"`
+	}{})
+	s.EqualError(err, "hosts: invalid merge strategy \"prepend\"")
+}
+
+func (s *configItemTestSuite) TestCreateConfigItemsWithPointers() {
+	type section struct {
+		Port int `default:"80"`
+		Host string
+	}
+
+	cfgs, err := createConfigItems(struct {
+		Count   *int `default:"1"`
+		Enabled *bool
+		Name    string
+		Server  *section
+	}{})
+	s.NoError(err)
+	s.Equal(5, len(cfgs))
+
+	s.Equal("count", cfgs[0].name)
+	s.Equal(reflect.Int, cfgs[0].kind)
+	s.Equal(1, cfgs[0].defaultValue)
+	s.True(cfgs[0].optional)
+
+	s.Equal("enabled", cfgs[1].name)
+	s.Equal(false, cfgs[1].defaultValue)
+	s.True(cfgs[1].optional)
+
+	s.False(cfgs[2].optional)
+
+	s.Equal("server.port", cfgs[3].name)
+	s.Equal([]int{3, 0}, cfgs[3].index)
+	s.True(cfgs[3].optional)
+	s.Equal("server.host", cfgs[4].name)
+	s.True(cfgs[4].optional)
+
+	_, err = createConfigItems(struct {
+		Counts []*int
+	}{})
+	s.EqualError(err, "counts: unsupported type []*int")
+}
+
 func TestConfigItemTestSuite(t *testing.T) {
 	suite.Run(t, new(configItemTestSuite))
 }
@@ -132,6 +319,20 @@ type floatConfigTest struct {
 	foofloat float32 `desc:"foofloat" default:"1.23"`
 }
 
+type scalarConfigTest struct {
+	i8  int8    `default:"-8"`
+	i16 int16   `default:"16"`
+	i32 int32   `default:"32"`
+	i64 int64   `default:"0x40"`
+	u   uint    `default:"1"`
+	u8  uint8   `default:"255"`
+	u16 uint16  `default:"16"`
+	u32 uint32  `default:"32"`
+	u64 uint64  `default:"64"`
+	f64 float64 `default:"6.4"`
+	nod uint16
+}
+
 type configTest struct {
 	foo string `desc:"foo" default:"foo" required:"true" hidden:"false"`
 	bar string `desc:"bar" default:"bar" required:"false" hidden:"true"`
@@ -0,0 +1,320 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	deprecatedKey = "deprecated"
+	aliasesKey    = "aliases"
+)
+
+// aliasFlagNames returns the flags of the old keys of the item.
+func (item configItem) aliasFlagNames() []string {
+	names := make([]string, len(item.aliases))
+	for i, alias := range item.aliases {
+		if item.command != "" {
+			alias = strings.TrimPrefix(alias, item.command+".")
+		}
+		names[i] = toFlagName(alias)
+	}
+
+	return names
+}
+
+// addLegacyAliases adds the legacy key of the items to their aliases, so that the config
+// files, flags and environment variables using the lowercase field names keep working.
+// A legacy key is left out when it is the key or the flag of another item.
+func addLegacyAliases(items []configItem) {
+	taken := map[string]bool{}
+	for _, item := range items {
+		taken[item.name] = true
+		taken[item.command+" --"+item.flagName()] = true
+
+		for _, alias := range item.aliases {
+			taken[alias] = true
+		}
+		for _, name := range item.aliasFlagNames() {
+			taken[item.command+" --"+name] = true
+		}
+	}
+
+	for i, item := range items {
+		if item.legacyKey == "" || contains(item.aliases, item.legacyKey) {
+			continue
+		}
+
+		flag := item.command + " --" + toFlagName(strings.TrimPrefix(item.legacyKey, item.command+"."))
+		if taken[item.legacyKey] || taken[flag] {
+			continue
+		}
+
+		taken[item.legacyKey] = true
+		taken[flag] = true
+		items[i].aliases = append(items[i].aliases, item.legacyKey)
+	}
+}
+
+// addDeprecatedFlags marks the flag of a deprecated item and adds the deprecated flags of its
+// old keys. They share the value of the flag and are hidden from the help.
+func (c *Cmder) addDeprecatedFlags(item configItem, flags *pflag.FlagSet, flagName string) error {
+	if item.deprecated == "" && item.aliases == nil {
+		return nil
+	}
+
+	if item.deprecated != "" {
+		if err := flags.MarkDeprecated(flagName, item.deprecated); err != nil {
+			return err
+		}
+	}
+
+	flag := flags.Lookup(flagName)
+
+	for _, name := range item.aliasFlagNames() {
+		if flags.Lookup(name) != nil {
+			return fmt.Errorf("%s: flag --%s is already defined", item.name, name)
+		}
+
+		flags.AddFlag(&pflag.Flag{
+			Name:        name,
+			Usage:       flag.Usage,
+			Value:       flag.Value,
+			DefValue:    flag.DefValue,
+			NoOptDefVal: flag.NoOptDefVal,
+		})
+
+		if err := flags.MarkDeprecated(name, "use --"+flagName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasDeprecations reports whether an item of the Cmder or of its children is deprecated or
+// has old keys.
+func (c *Cmder) hasDeprecations() bool {
+	for _, item := range c.items {
+		if item.deprecated != "" || item.aliases != nil {
+			return true
+		}
+	}
+
+	for _, child := range c.children {
+		if child.hasDeprecations() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// discardFlagWarnings discards the deprecation messages printed by pflag while parsing
+// the flags of the command and of its subcommands.
+func discardFlagWarnings(cmd *cobra.Command) {
+	cmd.Flags().SetOutput(io.Discard)
+
+	for _, sub := range cmd.Commands() {
+		discardFlagWarnings(sub)
+	}
+}
+
+// applyFlagAliases marks the flag of an item as changed when one of its deprecated flags is
+// set, so that the required flags are satisfied and Viper resolves the value.
+func (c *Cmder) applyFlagAliases() {
+	for _, item := range c.items {
+		if item.aliases == nil || item.isHidden || item.arg != "" {
+			continue
+		}
+
+		flags := c.commands[item.command].PersistentFlags()
+		for _, name := range item.aliasFlagNames() {
+			if f := flags.Lookup(name); f != nil && f.Changed {
+				flags.Lookup(item.flagName()).Changed = true
+			}
+		}
+	}
+
+	for _, child := range c.children {
+		child.applyFlagAliases()
+	}
+}
+
+// collectAliases adds the keys of the items by their old keys.
+func (c *Cmder) collectAliases(aliases map[string]string) {
+	for _, item := range c.items {
+		for _, alias := range item.aliases {
+			aliases[c.keyPrefix+alias] = c.key(item)
+		}
+	}
+
+	for _, child := range c.children {
+		child.collectAliases(aliases)
+	}
+}
+
+// renameAliases moves the values of the old keys of a config file to their new key, unless
+// the file also sets the new key, and returns the old keys found.
+func renameAliases(settings map[string]any, lines map[string]int, aliases map[string]string) []string {
+	var renamed []string
+
+	for alias, key := range aliases {
+		value, ok := lookupNested(settings, strings.Split(alias, "."))
+		if !ok {
+			continue
+		}
+
+		renamed = append(renamed, alias)
+		deleteNested(settings, strings.Split(alias, "."))
+
+		if _, ok := lookupNested(settings, strings.Split(key, ".")); ok {
+			continue
+		}
+
+		setNested(settings, strings.Split(key, "."), value)
+		lines[key] = lines[alias]
+	}
+
+	return renamed
+}
+
+func lookupNested(settings map[string]any, parts []string) (any, bool) {
+	for _, part := range parts[:len(parts)-1] {
+		m, ok := settings[part].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		settings = m
+	}
+
+	value, ok := settings[parts[len(parts)-1]]
+
+	return value, ok
+}
+
+func deleteNested(settings map[string]any, parts []string) {
+	for _, part := range parts[:len(parts)-1] {
+		m, ok := settings[part].(map[string]any)
+		if !ok {
+			return
+		}
+		settings = m
+	}
+
+	delete(settings, parts[len(parts)-1])
+}
+
+// aliasEnvNames returns the environment variables of the old keys of the item.
+func (c *Cmder) aliasEnvNames(item configItem) []string {
+	names := make([]string, len(item.aliases))
+	for i, alias := range item.aliases {
+		names[i] = toEnvName(c.root().envPrefix, c.keyPrefix+alias)
+	}
+
+	return names
+}
+
+// collectDeprecations returns the deprecated flags, environment variables and config keys set
+// for the run, with where they are set and what to use instead.
+func (c *Cmder) collectDeprecations() []string {
+	var used []string
+
+	for _, item := range c.items {
+		if item.arg != "" || item.deprecated == "" && item.aliases == nil {
+			continue
+		}
+
+		used = append(used, c.itemDeprecations(item)...)
+	}
+
+	for _, child := range c.children {
+		used = append(used, child.collectDeprecations()...)
+	}
+
+	return used
+}
+
+func (c *Cmder) itemDeprecations(item configItem) []string {
+	var used []string
+
+	key := c.key(item)
+	keyEnvs := c.withSecretFiles(item, c.keyEnvNames(item))
+	aliasSources := c.root().aliasSources
+
+	aliasFlag, aliasFile := false, false
+	if !item.isHidden {
+		flags := c.commands[item.command].PersistentFlags()
+		for _, name := range item.aliasFlagNames() {
+			if flags.Lookup(name).Changed {
+				aliasFlag = true
+				used = append(used, fmt.Sprintf("flag --%s: use --%s", name, item.flagName()))
+			}
+		}
+	}
+
+	for _, env := range c.withSecretFiles(item, c.aliasEnvNames(item)) {
+		if os.Getenv(env) != "" {
+			used = append(used, fmt.Sprintf("env %s: use %s", env, keyEnvs[0]))
+		}
+	}
+
+	for _, alias := range item.aliases {
+		if source, ok := aliasSources[c.keyPrefix+alias]; ok {
+			aliasFile = true
+			used = append(used, fmt.Sprintf("%s: key %s: use %s", source, c.keyPrefix+alias, key))
+		}
+	}
+
+	if item.deprecated == "" {
+		return used
+	}
+
+	if f := c.commands[item.command].PersistentFlags().Lookup(item.flagName()); f != nil && f.Changed && !aliasFlag {
+		used = append(used, fmt.Sprintf("flag --%s: %s", f.Name, item.deprecated))
+	}
+
+	for _, env := range keyEnvs {
+		if os.Getenv(env) != "" {
+			used = append(used, fmt.Sprintf("env %s: %s", env, item.deprecated))
+		}
+	}
+
+	if source, ok := c.root().fileSources[key]; ok && !aliasFile {
+		used = append(used, fmt.Sprintf("%s: key %s: %s", source, key, item.deprecated))
+	}
+
+	return used
+}
+
+// warnDeprecated prints a single warning listing the deprecated inputs set for the run.
+func (c *Cmder) warnDeprecated(w io.Writer) {
+	used := c.collectDeprecations()
+	if len(used) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "warning: deprecated config in use:")
+	for _, u := range used {
+		fmt.Fprintf(w, "  %s\n", u)
+	}
+}
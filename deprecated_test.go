@@ -0,0 +1,201 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type deprecatedTestSuite struct {
+	suite.Suite
+	out bytes.Buffer
+	err bytes.Buffer
+	fs  afero.Fs
+}
+
+type deprecatedConfig struct {
+	Server struct {
+		Address string `default:"localhost" aliases:"server.url,server.host"`
+	}
+	Verbose bool   `deprecated:"use --log-level"`
+	Level   string `key:"log_level" default:"info"`
+}
+
+func (s *deprecatedTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.out.Reset()
+	s.err.Reset()
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", nil, 0644))
+}
+
+func (s *deprecatedTestSuite) execute(args ...string) (*deprecatedConfig, *Cmder, error) {
+	var cfg *deprecatedConfig
+	cmder, err := New(func(_ context.Context, c *deprecatedConfig) error {
+		cfg = c
+		return nil
+	}, WithFS(s.fs), WithPrefix("APP"), WithConfigFile("/config.yaml"), WithConfigMode(ConfigStrict))
+	s.NoError(err)
+
+	cmder.Cobra().SetOut(&s.out)
+	cmder.Cobra().SetErr(&s.err)
+	cmder.Cobra().SetArgs(args)
+
+	return cfg, cmder, cmder.Execute()
+}
+
+func (s *deprecatedTestSuite) TestNoWarning() {
+	cfg, _, err := s.execute("--server-address", "example.com")
+
+	s.NoError(err)
+	s.Equal("example.com", cfg.Server.Address)
+	s.Empty(s.err.String())
+}
+
+func (s *deprecatedTestSuite) TestAliasFlag() {
+	cfg, cmder, err := s.execute("--server-url", "example.com")
+
+	s.NoError(err)
+	s.Equal("example.com", cfg.Server.Address)
+	s.Equal("warning: deprecated config in use:\n  flag --server-url: use --server-address\n", s.err.String())
+
+	source, ok := cmder.Source("server.address")
+	s.True(ok)
+	s.Equal(Source{Kind: SourceFlag, Name: "server-url"}, source)
+}
+
+func (s *deprecatedTestSuite) TestAliasEnv() {
+	s.T().Setenv("APP_SERVER_HOST", "example.com")
+
+	cfg, _, err := s.execute()
+
+	s.NoError(err)
+	s.Equal("example.com", cfg.Server.Address)
+	s.Equal("warning: deprecated config in use:\n  env APP_SERVER_HOST: use APP_SERVER_ADDRESS\n", s.err.String())
+}
+
+func (s *deprecatedTestSuite) TestAliasConfigKey() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("server:\n  url: example.com\n"), 0644))
+
+	cfg, cmder, err := s.execute()
+
+	s.NoError(err)
+	s.Equal("example.com", cfg.Server.Address)
+	s.Equal("warning: deprecated config in use:\n  file /config.yaml:2: key server.url: use server.address\n", s.err.String())
+
+	source, _ := cmder.Source("server.address")
+	s.Equal(Source{Kind: SourceFile, File: "/config.yaml", Line: 2}, source)
+}
+
+func (s *deprecatedTestSuite) TestNewKeyTakesPrecedence() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("server:\n  url: old.com\n  address: new.com\n"), 0644))
+
+	cfg, _, err := s.execute()
+
+	s.NoError(err)
+	s.Equal("new.com", cfg.Server.Address)
+}
+
+func (s *deprecatedTestSuite) TestDeprecatedKey() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("verbose: true\n"), 0644))
+	s.T().Setenv("APP_VERBOSE", "true")
+
+	cfg, _, err := s.execute("--verbose", "--server-host", "example.com")
+
+	s.NoError(err)
+	s.True(cfg.Verbose)
+	s.Equal(`warning: deprecated config in use:
+  flag --server-host: use --server-address
+  flag --verbose: use --log-level
+  env APP_VERBOSE: use --log-level
+  file /config.yaml:1: key verbose: use --log-level
+`, s.err.String())
+}
+
+func (s *deprecatedTestSuite) TestDeprecatedFlagsOfAddedCommand() {
+	cmder, err := New(func(context.Context, *deprecatedConfig) error { return nil },
+		WithFS(s.fs), WithPrefix("APP"), WithConfigFile("/config.yaml"), WithConfigInit())
+	s.NoError(err)
+
+	child, err := New(func(context.Context, *struct{ Name string }) error { return nil })
+	s.NoError(err)
+	s.NoError(cmder.AddCommand("child", child))
+
+	cmder.Cobra().SetOut(&s.out)
+	cmder.Cobra().SetErr(&s.err)
+	cmder.Cobra().SetArgs([]string{"child", "--verbose", "--server-url", "example.com"})
+
+	s.NoError(cmder.Execute())
+	s.Empty(s.out.String())
+	s.Equal("warning: deprecated config in use:\n  flag --server-url: use --server-address\n  flag --verbose: use --log-level\n", s.err.String())
+
+	s.err.Reset()
+	cmder.Cobra().SetArgs([]string{"config", "init", "--verbose"})
+
+	s.NoError(cmder.Execute())
+	s.NotContains(s.out.String(), "has been deprecated")
+	s.NotContains(s.err.String(), "has been deprecated")
+}
+
+func (s *deprecatedTestSuite) TestRequiredAliasFlag() {
+	type config struct {
+		Address string `aliases:"url" required:"true"`
+	}
+
+	var cfg *config
+	cmder, err := New(func(_ context.Context, c *config) error {
+		cfg = c
+		return nil
+	})
+	s.NoError(err)
+
+	cmder.Cobra().SetErr(&s.err)
+	cmder.Cobra().SetArgs([]string{"--url", "example.com"})
+
+	s.NoError(cmder.Execute())
+	s.Equal("example.com", cfg.Address)
+}
+
+func (s *deprecatedTestSuite) TestHelp() {
+	_, _, err := s.execute("--help")
+
+	s.NoError(err)
+	s.Contains(s.out.String(), "--server-address")
+	s.Contains(s.out.String(), "--log-level")
+	s.NotContains(s.out.String(), "--server-url")
+	s.NotContains(s.out.String(), "--verbose")
+}
+
+func (s *deprecatedTestSuite) TestInvalidTags() {
+	_, err := createConfigItems(struct {
+		Verbose bool `deprecated:""`
+	}{})
+	s.EqualError(err, "verbose: deprecated requires a message")
+
+	_, err = New[struct {
+		Name  string
+		Label string `aliases:"name"`
+	}](nil)
+	s.EqualError(err, "label: flag --name is already defined")
+}
+
+func TestDeprecatedTestSuite(t *testing.T) {
+	suite.Run(t, new(deprecatedTestSuite))
+}
@@ -0,0 +1,94 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const enumKey = "enum"
+
+// Enumer is implemented by the types of the config fields accepting a fixed set of values.
+// The values are listed in the help, completed by the shells and checked like the `enum` tag.
+type Enumer interface {
+	Enum() []string
+}
+
+var enumerType = reflect.TypeOf((*Enumer)(nil)).Elem()
+
+// enumValues returns the allowed values of the `enum` or `oneof` tag, or of the Enum
+// method of the type of the item.
+func (item configItem) enumValues(sf reflect.StructField) ([]string, error) {
+	enum, hasEnum := sf.Tag.Lookup(enumKey)
+	oneof, hasOneof := sf.Tag.Lookup(oneofKey)
+
+	switch {
+	case hasEnum && hasOneof:
+		return nil, fmt.Errorf("%s: enum and oneof are exclusive", item.name)
+	case hasEnum:
+		var values []string
+		for _, value := range strings.Split(enum, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		if values == nil {
+			return nil, fmt.Errorf("%s: enum requires at least one value", item.name)
+		}
+		return values, nil
+	case hasOneof:
+		return strings.Fields(oneof), nil
+	}
+
+	// The item type is the underlying type of the field, the method is on the field type.
+	typ := sf.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if item.elem != nil {
+		typ = typ.Elem()
+	}
+
+	if reflect.PointerTo(typ).Implements(enumerType) {
+		return reflect.New(typ).Interface().(Enumer).Enum(), nil
+	}
+
+	return nil, nil
+}
+
+// addFlagChoices lists the allowed values of the item in the help of its flag
+// and completes them.
+func (c *Cmder) addFlagChoices(item configItem, flagName string) error {
+	values := item.rules.oneof
+	if values == nil {
+		return nil
+	}
+
+	cmd := c.commands[item.command]
+
+	flag := cmd.PersistentFlags().Lookup(flagName)
+	choices := "one of " + strings.Join(values, ", ")
+	if flag.Usage == "" {
+		flag.Usage = choices
+	} else {
+		flag.Usage += " (" + choices + ")"
+	}
+
+	return cmd.RegisterFlagCompletionFunc(flagName, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+}
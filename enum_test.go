@@ -0,0 +1,121 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type enumTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type environment string
+
+func (environment) Enum() []string {
+	return []string{"dev", "staging", "prod"}
+}
+
+type enumConfig struct {
+	Format string      `desc:"Log format" enum:"json, text, logfmt" default:"text"`
+	Env    environment `default:"dev"`
+	Levels []int       `enum:"1,2,3"`
+}
+
+func (s *enumTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", nil, 0644))
+}
+
+func (s *enumTestSuite) execute(args ...string) (*enumConfig, error) {
+	var cfg *enumConfig
+	cmder, err := New(func(_ context.Context, c *enumConfig) error {
+		cfg = c
+		return nil
+	}, WithFS(s.fs), WithPrefix("APP"), WithConfigFile("/config.yaml"))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return cfg, cmder.Execute()
+}
+
+func (s *enumTestSuite) TestValid() {
+	cfg, err := s.execute("--format", "json", "--env", "prod", "--levels", "1,3")
+
+	s.NoError(err)
+	s.Equal("json", cfg.Format)
+	s.Equal(environment("prod"), cfg.Env)
+	s.Equal([]int{1, 3}, cfg.Levels)
+}
+
+func (s *enumTestSuite) TestInvalid() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("format: xml\n"), 0644))
+	s.T().Setenv("APP_ENV", "qa")
+
+	cfg, err := s.execute("--levels", "4")
+
+	s.Nil(cfg)
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, `format: must be one of json, text, logfmt, got "xml" (file /config.yaml:1)
+env: must be one of dev, staging, prod, got "qa" (env APP_ENV)
+levels: [0]: must be one of 1, 2, 3, got "4" (flag --levels)`)
+}
+
+func (s *enumTestSuite) TestHelp() {
+	_, err := s.execute("--help")
+	s.NoError(err)
+
+	s.Contains(s.buf.String(), `Log format (one of json, text, logfmt) (default "text")`)
+	s.Contains(s.buf.String(), `one of dev, staging, prod (default "dev")`)
+}
+
+func (s *enumTestSuite) TestCompletion() {
+	_, err := s.execute("__complete", "--format", "")
+	s.NoError(err)
+	s.True(strings.HasPrefix(s.buf.String(), "json\ntext\nlogfmt\n:4\n"))
+
+	s.buf.Reset()
+	_, err = s.execute("__complete", "--env", "st")
+	s.NoError(err)
+	s.Contains(s.buf.String(), "staging\n")
+}
+
+func (s *enumTestSuite) TestInvalidTags() {
+	_, err := createConfigItems(struct {
+		Format string `enum:"json" oneof:"text"`
+	}{})
+	s.EqualError(err, "format: enum and oneof are exclusive")
+
+	_, err = createConfigItems(struct {
+		Format string `enum:" , "`
+	}{})
+	s.EqualError(err, "format: enum requires at least one value")
+}
+
+func TestEnumTestSuite(t *testing.T) {
+	suite.Run(t, new(enumTestSuite))
+}
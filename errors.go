@@ -0,0 +1,119 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUsage is matched by errors raised while parsing the command line,
+	// such as an unknown flag, an invalid flag value or a missing required flag.
+	ErrUsage = errors.New("usage error")
+
+	// ErrConfig is matched by errors raised while reading or decoding the configuration.
+	ErrConfig = errors.New("config error")
+)
+
+const (
+	// ExitFailure is the exit code of an error without a more specific mapping.
+	ExitFailure = 1
+
+	// ExitUsage is the default exit code of an ErrUsage error.
+	ExitUsage = 2
+
+	// ExitConfig is the default exit code of an ErrConfig error (EX_CONFIG from sysexits.h).
+	ExitConfig = 78
+)
+
+// FieldError is a validation error of a config key. The errors of several keys
+// are joined, use errors.As to retrieve the first one.
+type FieldError struct {
+	// Key is the Viper key, e.g. "server.port".
+	Key string
+	// Source is where the invalid value comes from.
+	Source Source
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Source.Kind == SourceNone {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %v (%s)", e.Key, e.Err, e.Source)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCoder can be implemented by the errors returned from the run callback
+// to choose their own process exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+type exitCode struct {
+	target error
+	code   int
+}
+
+var defaultExitCodes = []exitCode{
+	{target: ErrUsage, code: ExitUsage},
+	{target: ErrConfig, code: ExitConfig},
+}
+
+type cmderError struct {
+	kind error
+	err  error
+}
+
+func (e *cmderError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cmderError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+// The mappings registered with WithExitCode are checked first, then the ExitCoder
+// interface and finally the ErrUsage and ErrConfig defaults. Any other error returns ExitFailure.
+func (c *Cmder) ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	for _, ec := range c.exitCodes {
+		if errors.Is(err, ec.target) {
+			return ec.code
+		}
+	}
+
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	for _, ec := range defaultExitCodes {
+		if errors.Is(err, ec.target) {
+			return ec.code
+		}
+	}
+
+	return ExitFailure
+}
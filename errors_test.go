@@ -0,0 +1,66 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type errorsTestSuite struct {
+	suite.Suite
+}
+
+func (s *errorsTestSuite) TestExitCode() {
+	errNotFound := errors.New("not found")
+
+	cmd := Cmder{}
+	WithExitCode(errNotFound, 44)(&cmd)
+
+	s.Equal(0, cmd.ExitCode(nil))
+	s.Equal(ExitFailure, cmd.ExitCode(errors.New("boom")))
+	s.Equal(ExitUsage, cmd.ExitCode(&cmderError{kind: ErrUsage, err: errors.New("bad flag")}))
+	s.Equal(ExitConfig, cmd.ExitCode(&cmderError{kind: ErrConfig, err: errors.New("bad file")}))
+	s.Equal(44, cmd.ExitCode(fmt.Errorf("wrapped: %w", errNotFound)))
+	s.Equal(5, cmd.ExitCode(exitCoderError{code: 5}))
+}
+
+func (s *errorsTestSuite) TestCmderErrorKeepsMessage() {
+	inner := errors.New("inner")
+	err := &cmderError{kind: ErrConfig, err: inner}
+
+	s.EqualError(err, "inner")
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorIs(err, inner)
+}
+
+func TestErrorsTestSuite(t *testing.T) {
+	suite.Run(t, new(errorsTestSuite))
+}
+
+type exitCoderError struct {
+	code int
+}
+
+func (e exitCoderError) Error() string {
+	return "exit coder"
+}
+
+func (e exitCoderError) ExitCode() int {
+	return e.code
+}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"os"
 
 	"github.com/ergagnon/gocmder"
@@ -22,16 +23,15 @@ import (
 )
 
 func main() {
-	cli, err := gocmder.NewCmder(internal.AppConfig{}, func(cfg any) {
-		app := internal.NewApp(cfg.(internal.AppConfig))
-		app.Run()		
+	cli, err := gocmder.New(func(_ context.Context, cfg *internal.AppConfig) error {
+		app := internal.NewApp(*cfg)
+		app.Run()
+		return nil
 	})
 
 	if err != nil {
 		os.Exit(1)
 	}
 
-	if err := cli.Execute(); err != nil {
-		os.Exit(1)
-	}
-}
\ No newline at end of file
+	os.Exit(cli.ExitCode(cli.Execute()))
+}
@@ -0,0 +1,185 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type namingTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type namingConfig struct {
+	ServerURL  string `default:"localhost"`
+	HTTPPort   int    `default:"80"`
+	Token      string `flag:"api-token" env:"APP_API_TOKEN,LEGACY_TOKEN"`
+	MaxRetries int    `key:"retries"`
+	LogLevel   string `mapstructure:"level" yaml:"ignored"`
+	Timeout    string `yaml:"timeout_s,omitempty" json:"ignored"`
+	Endpoint   string `json:"endpoint_url"`
+	Skipped    string `yaml:"-"`
+	TLSConfig  struct {
+		CertFile string
+	} `key:"tls"`
+}
+
+func (s *namingTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", nil, 0644))
+}
+
+func (s *namingTestSuite) execute(args ...string) (*namingConfig, error) {
+	var cfg *namingConfig
+	cmder, err := New(func(_ context.Context, c *namingConfig) error {
+		cfg = c
+		return nil
+	}, WithFS(s.fs), WithPrefix("APP"), WithConfigFile("/config.yaml"))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return cfg, cmder.Execute()
+}
+
+func (s *namingTestSuite) TestKeys() {
+	items, err := createConfigItems(namingConfig{})
+	s.NoError(err)
+
+	var names []string
+	for _, item := range items {
+		names = append(names, item.name)
+	}
+
+	s.Equal([]string{"server_url", "http_port", "token", "retries", "level", "timeout_s", "endpoint_url", "tls.cert_file"}, names)
+}
+
+func (s *namingTestSuite) TestFlags() {
+	cfg, err := s.execute("--server-url", "example.com", "--http-port", "8080", "--api-token", "t0k3n",
+		"--retries", "3", "--tls-cert-file", "cert.pem")
+
+	s.NoError(err)
+	s.Equal("example.com", cfg.ServerURL)
+	s.Equal(8080, cfg.HTTPPort)
+	s.Equal("t0k3n", cfg.Token)
+	s.Equal(3, cfg.MaxRetries)
+	s.Equal("cert.pem", cfg.TLSConfig.CertFile)
+}
+
+func (s *namingTestSuite) TestEnv() {
+	s.T().Setenv("APP_SERVER_URL", "example.com")
+	s.T().Setenv("APP_TLS_CERT_FILE", "cert.pem")
+	s.T().Setenv("LEGACY_TOKEN", "legacy")
+
+	cfg, err := s.execute()
+	s.NoError(err)
+	s.Equal("example.com", cfg.ServerURL)
+	s.Equal("cert.pem", cfg.TLSConfig.CertFile)
+	s.Equal("legacy", cfg.Token)
+
+	// The first alias takes precedence.
+	s.T().Setenv("APP_API_TOKEN", "current")
+
+	cfg, err = s.execute()
+	s.NoError(err)
+	s.Equal("current", cfg.Token)
+}
+
+func (s *namingTestSuite) TestConfigFile() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte(`server_url: example.com
+level: debug
+timeout_s: 5s
+endpoint_url: http://localhost
+tls:
+  cert_file: cert.pem
+`), 0644))
+
+	cfg, err := s.execute()
+	s.NoError(err)
+	s.Equal("example.com", cfg.ServerURL)
+	s.Equal("debug", cfg.LogLevel)
+	s.Equal("5s", cfg.Timeout)
+	s.Equal("http://localhost", cfg.Endpoint)
+	s.Equal("cert.pem", cfg.TLSConfig.CertFile)
+}
+
+func (s *namingTestSuite) TestSkipped() {
+	_, err := s.execute("--skipped", "value")
+	s.EqualError(err, "unknown flag: --skipped")
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("skipped: value\n"), 0644))
+
+	cfg, err := s.execute()
+	s.NoError(err)
+	s.Empty(cfg.Skipped)
+}
+
+func (s *namingTestSuite) TestLegacyKeys() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte(`serverurl: example.com
+tlsconfig:
+  certfile: cert.pem
+`), 0644))
+	s.T().Setenv("APP_HTTPPORT", "8080")
+
+	cfg, err := s.execute("--maxretries", "3")
+	s.NoError(err)
+	s.Equal("example.com", cfg.ServerURL)
+	s.Equal(8080, cfg.HTTPPort)
+	s.Equal(3, cfg.MaxRetries)
+	s.Equal("cert.pem", cfg.TLSConfig.CertFile)
+
+	s.Equal(`warning: deprecated config in use:
+  file /config.yaml:1: key serverurl: use server_url
+  env APP_HTTPPORT: use APP_HTTP_PORT
+  flag --maxretries: use --retries
+  file /config.yaml:3: key tlsconfig.certfile: use tls.cert_file
+`, s.buf.String())
+}
+
+func (s *namingTestSuite) TestDuplicateFlag() {
+	_, err := New[struct {
+		Name  string
+		Alias string `flag:"name"`
+	}](nil)
+
+	s.EqualError(err, "alias: flag --name is already defined")
+}
+
+func (s *namingTestSuite) TestToSnakeCase() {
+	for name, expected := range map[string]string{
+		"Name":      "name",
+		"ServerURL": "server_url",
+		"HTTPPort":  "http_port",
+		"UserID":    "user_id",
+		"HTTP2Port": "http2_port",
+		"maxItems":  "max_items",
+	} {
+		s.Equal(expected, toSnakeCase(name), name)
+	}
+}
+
+func TestNamingTestSuite(t *testing.T) {
+	suite.Run(t, new(namingTestSuite))
+}
@@ -14,7 +14,12 @@
 
 package gocmder
 
-import "github.com/spf13/afero"
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/afero"
+)
 
 type CmderOption func(*Cmder)
 
@@ -60,10 +65,121 @@ func WithConfigFile(file string) CmderOption {
 	}
 }
 
+// WithConfigSearch searches for a config file named name with any of the extensions supported
+// by Viper, e.g. name.yaml. Without paths, the directories searched in order are the working
+// directory, $XDG_CONFIG_HOME/<name>, $HOME/.<name> and /etc/<name>. A missing file is not an error.
+// The --config flag, the <PREFIX>_CONFIG environment variable and WithConfigFile take precedence.
+func WithConfigSearch(name string, paths ...string) CmderOption {
+	return func(c *Cmder) {
+		c.configName = name
+		c.configPaths = paths
+	}
+}
+
+// WithConfigFiles adds config files merged in order below the config file, e.g. a base config
+// shipped with the application and a site config in /etc. Later files override earlier ones:
+// maps are merged, scalars and lists are replaced unless the field is tagged `merge:"append"`.
+// Missing files are skipped.
+func WithConfigFiles(files ...string) CmderOption {
+	return func(c *Cmder) {
+		c.configFiles = append(c.configFiles, files...)
+	}
+}
+
+// WithExplainConfig adds the --explain-config flag. It prints the key, the effective value
+// and the source of every config value of the command, then exits without running it.
+func WithExplainConfig() CmderOption {
+	return func(c *Cmder) {
+		c.explainConfig = true
+	}
+}
+
+// WithPrintConfig adds the --print-config[=yaml|json|toml|env] flag. It prints the config
+// resolved for the command using the Viper keys, with the secret values redacted, then exits
+// without running it.
+func WithPrintConfig() CmderOption {
+	return func(c *Cmder) {
+		c.printConfig = true
+	}
+}
+
+// WithConfigInit adds the `config init [file]` command writing a sample config file with every key,
+// its default value and its description. An existing file is only overwritten with --force.
+func WithConfigInit() CmderOption {
+	return func(c *Cmder) {
+		c.configInit = true
+	}
+}
+
+// WithConfigSchema adds the hidden `config schema` command printing the JSON Schema of the config file.
+func WithConfigSchema() CmderOption {
+	return func(c *Cmder) {
+		c.configSchema = true
+	}
+}
+
+// WithConfigMode sets how the config files are checked against the config struct. ConfigStrict fails
+// on the unknown keys and the invalid values with their file and line, and suggests the closest key.
+// ConfigLenient only prints them as warnings, the invalid values still fail when they are decoded.
+func WithConfigMode(mode ConfigMode) CmderOption {
+	return func(c *Cmder) {
+		c.configMode = mode
+	}
+}
+
 // WithFS sets the filesystem to use for the command.
 // This is useful for testing.
 func WithFS(fs afero.Fs) CmderOption {
 	return func(c *Cmder) {
+		c.fs = fs
 		c.Viper().SetFs(fs)
 	}
 }
+
+// WithExitCode maps the errors matching target (using errors.Is) to a process exit code.
+// This is used by the ExitCode method. Mappings are checked in the order they are added.
+func WithExitCode(target error, code int) CmderOption {
+	return func(c *Cmder) {
+		c.exitCodes = append(c.exitCodes, exitCode{target: target, code: code})
+	}
+}
+
+// WithCommand sets the callback run by a subcommand declared with the `cmd` tag.
+// The path is the name of the command and its parents separated by spaces, e.g. "migrate up".
+// The callback receives the whole config so that the values of the parent commands are available.
+func WithCommand[T any](path string, run RunFunc[T]) CmderOption {
+	return func(c *Cmder) {
+		if c.handlers == nil {
+			c.handlers = make(map[string]commandHandler)
+		}
+
+		c.handlers[strings.Join(strings.Fields(path), " ")] = newCommandHandler(run)
+	}
+}
+
+// WithWatch reloads the config while the command runs, when the config file or one of the
+// WithConfigFiles files changes. The new config is decoded and validated like at startup, then
+// onChange is called with the previous and the new config. An invalid change is reported and the
+// previous config is kept. The current config is returned by Config. The files are watched with
+// fsnotify on the OS file system and polled on the other file systems of WithFS.
+func WithWatch[T any](onChange func(old, new *T)) CmderOption {
+	return func(c *Cmder) {
+		c.watch = &watcher{
+			cfgType: reflect.TypeOf((*T)(nil)),
+			onChange: func(old, new any) {
+				if onChange != nil {
+					onChange(old.(*T), new.(*T))
+				}
+			},
+		}
+	}
+}
+
+// WithNamespace sets the prefix of the config keys and environment variables of a Cmder
+// mounted with AddCommand. It defaults to the command name, an empty namespace shares
+// the keys of the parent.
+func WithNamespace(namespace string) CmderOption {
+	return func(c *Cmder) {
+		c.namespace = &namespace
+	}
+}
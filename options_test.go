@@ -15,6 +15,8 @@
 package gocmder
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -54,6 +56,45 @@ func (s *optionsTestSuite) TestWithPrefix() {
 	s.Equal("APP", cmd.envPrefix)
 }
 
+func (s *optionsTestSuite) TestWithExitCode() {
+	cmd := Cmder{}
+	WithExitCode(ErrUsage, 64)(&cmd)
+
+	s.Equal([]exitCode{{target: ErrUsage, code: 64}}, cmd.exitCodes)
+}
+
+func (s *optionsTestSuite) TestWithCommand() {
+	cmd := Cmder{}
+	WithCommand(" migrate   up ", func(context.Context, *appConfig) error { return nil })(&cmd)
+
+	s.Contains(cmd.handlers, "migrate up")
+	s.Equal(reflect.TypeOf(&appConfig{}), cmd.handlers["migrate up"].cfgType)
+}
+
+func (s *optionsTestSuite) TestWithNamespace() {
+	cmd := Cmder{}
+	WithNamespace("")(&cmd)
+
+	s.NotNil(cmd.namespace)
+	s.Equal("", *cmd.namespace)
+}
+
+func (s *optionsTestSuite) TestWithConfigSearch() {
+	cmd := Cmder{}
+	WithConfigSearch("app", "/opt/app")(&cmd)
+
+	s.Equal("app", cmd.configName)
+	s.Equal([]string{"/opt/app"}, cmd.configPaths)
+}
+
+func (s *optionsTestSuite) TestWithConfigFiles() {
+	cmd := Cmder{}
+	WithConfigFiles("/usr/share/app/base.yaml")(&cmd)
+	WithConfigFiles("/etc/app/site.yaml")(&cmd)
+
+	s.Equal([]string{"/usr/share/app/base.yaml", "/etc/app/site.yaml"}, cmd.configFiles)
+}
+
 func TestOptionsTestSuite(t *testing.T) {
 	suite.Run(t, new(optionsTestSuite))
 }
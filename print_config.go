@@ -0,0 +1,226 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	printConfigFlagName = "print-config"
+
+	// redacted replaces the value of the secret fields.
+	redacted = "******"
+)
+
+var printFormats = []string{"yaml", "json", "toml", "env"}
+
+// printFormat is the value of the --print-config flag.
+type printFormat string
+
+func (f *printFormat) String() string {
+	return string(*f)
+}
+
+func (f *printFormat) Set(value string) error {
+	for _, format := range printFormats {
+		if value == format {
+			*f = printFormat(value)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of %s", strings.Join(printFormats, ", "))
+}
+
+func (f *printFormat) Type() string {
+	return "format"
+}
+
+// addPrintConfigFlag adds the persistent --print-config flag, defaulting to yaml without a value.
+func (c *Cmder) addPrintConfigFlag() error {
+	if c.cobra.PersistentFlags().Lookup(printConfigFlagName) != nil {
+		return fmt.Errorf("flag %q is reserved to print the config", printConfigFlagName)
+	}
+
+	format := printFormat("yaml")
+	flag := c.cobra.PersistentFlags().VarPF(&format, printConfigFlagName, "",
+		fmt.Sprintf("print the config in the %s format and exit", strings.Join(printFormats, ", ")))
+	flag.NoOptDefVal = "yaml"
+
+	return nil
+}
+
+// printRequested returns the format given to --print-config for the command.
+func printRequested(cmd *cobra.Command) (string, bool) {
+	f := cmd.Flags().Lookup(printConfigFlagName)
+	if f == nil || !f.Changed {
+		return "", false
+	}
+
+	return f.Value.String(), true
+}
+
+// writeConfig writes the decoded config of the command and of its parents using the Viper keys.
+// The secret values are redacted and the unset optional values are left out.
+func (c *Cmder) writeConfig(w io.Writer, command, format string) error {
+	cfg := reflect.ValueOf(c.cfg).Elem()
+
+	settings := make(map[string]any)
+	var env strings.Builder
+
+	for _, item := range c.items {
+		if item.arg != "" || !item.inCommand(command) {
+			continue
+		}
+
+		field, ok := item.fieldValue(cfg)
+		if !ok {
+			continue
+		}
+
+		if format == "env" {
+			value := redacted
+			if !item.isSecret {
+				value = item.formatValue(item.convert(field).Interface())
+			}
+			fmt.Fprintf(&env, "%s=%s\n", c.envNames(item)[0], quoteEnvValue(value))
+			continue
+		}
+
+		value := any(redacted)
+		if !item.isSecret {
+			value = item.printValue(field)
+		}
+		setNested(settings, strings.Split(c.key(item), "."), value)
+	}
+
+	var out []byte
+	var err error
+
+	switch format {
+	case "env":
+		out = []byte(env.String())
+	case "json":
+		if out, err = json.MarshalIndent(settings, "", "  "); err == nil {
+			out = append(out, '\n')
+		}
+	case "toml":
+		out, err = toml.Marshal(settings)
+	default:
+		out, err = yaml.Marshal(settings)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+
+	return err
+}
+
+// inCommand reports whether the item belongs to the command or to one of its parents.
+func (item configItem) inCommand(command string) bool {
+	return item.command == "" || item.command == command || strings.HasPrefix(command, item.command+".")
+}
+
+// fieldValue returns the decoded value of the item, or false when the value
+// or its section is a nil pointer.
+func (item configItem) fieldValue(v reflect.Value) (reflect.Value, bool) {
+	for _, x := range item.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	// The items of a pointer section are optional without being pointers themselves,
+	// and the pointer types such as *url.URL are the type of the item.
+	if item.optional && v.Kind() == reflect.Pointer && v.Type() != item.typ {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+
+	return v, true
+}
+
+// convert converts a field value to the item type, e.g. a named string to a string.
+func (item configItem) convert(v reflect.Value) reflect.Value {
+	if v.Type() != item.typ && v.Type().ConvertibleTo(item.typ) {
+		return v.Convert(item.typ)
+	}
+
+	return v
+}
+
+// printValue returns a value that serializes like the config file accepting it.
+func (item configItem) printValue(v reflect.Value) any {
+	switch {
+	case item.parser != nil, item.typ == durationType, item.typ == timeType:
+		return item.formatValue(item.convert(v).Interface())
+	case item.kind == reflect.Slice:
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = item.elem.printValue(v.Index(i))
+		}
+		return values
+	case item.kind == reflect.Map:
+		entries := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			entries[iter.Key().String()] = item.elem.printValue(iter.Value())
+		}
+		return entries
+	}
+
+	return item.convert(v).Interface()
+}
+
+// setNested sets a value in nested maps following the parts of a key.
+func setNested(settings map[string]any, parts []string, value any) {
+	for _, part := range parts[:len(parts)-1] {
+		m, ok := settings[part].(map[string]any)
+		if !ok {
+			m = make(map[string]any)
+			settings[part] = m
+		}
+		settings = m
+	}
+
+	settings[parts[len(parts)-1]] = value
+}
+
+// quoteEnvValue quotes a value of the env format when it contains spaces, quotes or a comment.
+func quoteEnvValue(value string) string {
+	if strings.ContainsAny(value, " \t\n\"'#\\$") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
@@ -0,0 +1,229 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type printConfigTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+}
+
+type printedConfig struct {
+	Name     string        `default:"app"`
+	Password string        `secret:"true" default:"hunter2"`
+	Timeout  time.Duration `default:"5s"`
+	Retries  *int
+	Hosts    []string `default:"a,b"`
+	Labels   map[string]string
+	Server   struct {
+		Port int  `default:"8080"`
+		TLS  bool `default:"true"`
+	}
+}
+
+func (s *printConfigTestSuite) SetupTest() {
+	s.buf.Reset()
+}
+
+func (s *printConfigTestSuite) execute(args ...string) (bool, error) {
+	var run bool
+	cmder, err := New(func(_ context.Context, _ *printedConfig) error {
+		run = true
+		return nil
+	}, WithPrefix("APP"), WithPrintConfig())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return run, cmder.Execute()
+}
+
+func (s *printConfigTestSuite) TestPrintConfigYAML() {
+	s.T().Setenv("APP_SERVER_PORT", "9090")
+
+	run, err := s.execute("--print-config", "--labels", "env=dev")
+	s.NoError(err)
+	s.False(run)
+
+	s.Equal(`hosts:
+    - a
+    - b
+labels:
+    env: dev
+name: app
+password: '******'
+server:
+    port: 9090
+    tls: true
+timeout: 5s
+`, s.buf.String())
+}
+
+func (s *printConfigTestSuite) TestPrintConfigJSON() {
+	run, err := s.execute("--print-config=json", "--retries", "3")
+	s.NoError(err)
+	s.False(run)
+
+	s.JSONEq(`{
+		"hosts": ["a", "b"],
+		"labels": {},
+		"name": "app",
+		"password": "******",
+		"retries": 3,
+		"server": {"port": 8080, "tls": true},
+		"timeout": "5s"
+	}`, s.buf.String())
+}
+
+func (s *printConfigTestSuite) TestPrintConfigTOML() {
+	_, err := s.execute("--print-config=toml", "--name", "my app")
+	s.NoError(err)
+
+	s.Equal(`hosts = ['a', 'b']
+name = 'my app'
+password = '******'
+timeout = '5s'
+
+[labels]
+
+[server]
+port = 8080
+tls = true
+`, s.buf.String())
+}
+
+func (s *printConfigTestSuite) TestPrintConfigEnv() {
+	_, err := s.execute("--print-config=env", "--name", "my app")
+	s.NoError(err)
+
+	s.Equal(`APP_NAME="my app"
+APP_PASSWORD=******
+APP_TIMEOUT=5s
+APP_HOSTS=a,b
+APP_LABELS=
+APP_SERVER_PORT=8080
+APP_SERVER_TLS=true
+`, s.buf.String())
+}
+
+func (s *printConfigTestSuite) TestPrintConfigWithInvalidFormat() {
+	run, err := s.execute("--print-config=xml")
+
+	s.ErrorIs(err, ErrUsage)
+	s.ErrorContains(err, "must be one of yaml, json, toml, env")
+	s.False(run)
+}
+
+func (s *printConfigTestSuite) TestRunWithoutPrintConfig() {
+	run, err := s.execute()
+
+	s.NoError(err)
+	s.True(run)
+	s.Empty(s.buf.String())
+}
+
+func (s *printConfigTestSuite) TestPrintConfigOfGroupCommand() {
+	type config struct {
+		Name  string `default:"app"`
+		Serve struct {
+			Port int `default:"8080"`
+		} `cmd:"serve"`
+		Migrate struct {
+			DSN string `default:"postgres://db"`
+			Up  struct {
+				Steps int `default:"1"`
+			} `cmd:"up"`
+		} `cmd:"migrate"`
+	}
+
+	execute := func(args ...string) error {
+		cmder, err := New[config](nil, WithPrintConfig(),
+			WithCommand("migrate up", func(context.Context, *config) error { return nil }))
+		s.NoError(err)
+
+		cmder.Cobra().SetOutput(&s.buf)
+		cmder.Cobra().SetArgs(args)
+
+		return cmder.Execute()
+	}
+
+	s.NoError(execute("--print-config"))
+	s.Equal("name: app\n", s.buf.String())
+
+	s.buf.Reset()
+	s.NoError(execute("serve", "--print-config", "--port", "9090"))
+	s.Equal("name: app\nserve:\n    port: 9090\n", s.buf.String())
+
+	s.buf.Reset()
+	s.NoError(execute("migrate", "--print-config=env"))
+	s.Equal("NAME=app\nMIGRATE_DSN=postgres://db\n", s.buf.String())
+
+	s.buf.Reset()
+	s.NoError(execute("migrate"))
+	s.Contains(s.buf.String(), "Available Commands:")
+}
+
+func (s *printConfigTestSuite) TestPrintConfigPointerSection() {
+	type config struct {
+		Server *struct {
+			Port     int `min:"1"`
+			Endpoint *url.URL
+		}
+	}
+
+	execute := func(args ...string) (*config, error) {
+		var cfg *config
+		cmder, err := New(func(_ context.Context, c *config) error {
+			cfg = c
+			return nil
+		}, WithPrintConfig())
+		s.NoError(err)
+
+		cmder.Cobra().SetOutput(&s.buf)
+		cmder.Cobra().SetArgs(args)
+
+		return cfg, cmder.Execute()
+	}
+
+	cfg, err := execute()
+	s.NoError(err)
+	s.Nil(cfg.Server)
+
+	cfg, err = execute("--server-port", "80", "--server-endpoint", "http://localhost")
+	s.NoError(err)
+	s.Equal(80, cfg.Server.Port)
+
+	_, err = execute("--server-port", "0")
+	s.EqualError(err, "server.port: must be at least 1, got 0 (flag --server-port)")
+
+	s.buf.Reset()
+	_, err = execute("--server-port", "80", "--server-endpoint", "http://localhost", "--print-config")
+	s.NoError(err)
+	s.Equal("server:\n    endpoint: http://localhost\n    port: 80\n", s.buf.String())
+}
+
+func TestPrintConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(printConfigTestSuite))
+}
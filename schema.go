@@ -0,0 +1,218 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema describing a config file.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 any                    `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Minimum              any                    `json:"minimum,omitempty"`
+	Maximum              any                    `json:"maximum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+}
+
+// intBounds are the bounds of the sized integer kinds.
+var intBounds = map[reflect.Kind][2]any{
+	reflect.Int8:   {math.MinInt8, math.MaxInt8},
+	reflect.Int16:  {math.MinInt16, math.MaxInt16},
+	reflect.Int32:  {math.MinInt32, math.MaxInt32},
+	reflect.Uint8:  {0, math.MaxUint8},
+	reflect.Uint16: {0, math.MaxUint16},
+	reflect.Uint32: {0, math.MaxUint32},
+	reflect.Uint:   {0, nil},
+	reflect.Uint64: {0, nil},
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the config file, with the type,
+// the description, the default value and the bounds of every key. The required keys are
+// the keys tagged `required:"true"`.
+func (c *Cmder) JSONSchema() ([]byte, error) {
+	root := &jsonSchema{Schema: schemaDraft, Type: "object", Properties: map[string]*jsonSchema{}}
+	c.addSchemaProperties(root)
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, '\n'), nil
+}
+
+// addSchemaProperties adds the keys of the Cmder and of its children to the schema.
+func (c *Cmder) addSchemaProperties(root *jsonSchema) {
+	for _, item := range c.items {
+		if item.arg != "" {
+			continue
+		}
+
+		parts := strings.Split(c.key(item), ".")
+
+		section := root
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := section.Properties[part]
+			if !ok {
+				child = &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+				section.Properties[part] = child
+			}
+			section = child
+		}
+
+		name := parts[len(parts)-1]
+		section.Properties[name] = item.schema()
+
+		if item.isRequired {
+			section.Required = append(section.Required, name)
+		}
+	}
+
+	for _, child := range c.children {
+		child.addSchemaProperties(root)
+	}
+}
+
+// schema returns the JSON Schema of the values of the item, with the bounds
+// of its validation tags.
+func (item configItem) schema() *jsonSchema {
+	s := item.valueSchema()
+	s.Description = item.desc
+
+	scalar := s
+	switch item.kind {
+	case reflect.Slice:
+		scalar = s.Items
+		s.MinItems, s.MaxItems = item.rules.minLen, item.rules.maxLen
+	case reflect.Map:
+		scalar = s.AdditionalProperties
+		s.MinProperties, s.MaxProperties = item.rules.minLen, item.rules.maxLen
+	case reflect.String:
+		s.MinLength, s.MaxLength = item.rules.minLen, item.rules.maxLen
+	}
+	item.scalar().addRulesSchema(item.rules, scalar)
+
+	if item.hasDefaultValue && !item.isSecret {
+		s.Default = item.printValue(reflect.ValueOf(item.defaultValue))
+	}
+
+	if item.optional {
+		s.Type = []string{s.Type.(string), "null"}
+	}
+
+	return s
+}
+
+// addRulesSchema adds the min, max, oneof and pattern rules to the schema of a scalar.
+func (item configItem) addRulesSchema(rules validationRules, s *jsonSchema) {
+	// The bounds of the durations are in nanoseconds while their values are strings.
+	if rules.min != nil && item.typ != durationType {
+		s.Minimum = *rules.min
+	}
+	if rules.max != nil && item.typ != durationType {
+		s.Maximum = *rules.max
+	}
+
+	for _, value := range rules.oneof {
+		var enum any = value
+		if v, err := item.parse(value); err == nil {
+			enum = item.printValue(reflect.ValueOf(v))
+		}
+		s.Enum = append(s.Enum, enum)
+	}
+
+	if rules.pattern != nil {
+		s.Pattern = rules.pattern.String()
+	}
+}
+
+func (item configItem) valueSchema() *jsonSchema {
+	switch {
+	case item.typ == timeType && item.layout == time.RFC3339:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case item.parser != nil, item.typ == durationType, item.typ == timeType:
+		return &jsonSchema{Type: "string"}
+	}
+
+	switch item.kind {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice:
+		return &jsonSchema{Type: "array", Items: item.elem.valueSchema()}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: item.elem.valueSchema()}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	}
+
+	s := &jsonSchema{Type: "integer"}
+	if bounds, ok := intBounds[item.kind]; ok {
+		s.Minimum, s.Maximum = bounds[0], bounds[1]
+	}
+
+	return s
+}
+
+// addConfigSchemaCommand adds the hidden `config schema` command printing the JSON Schema.
+func (c *Cmder) addConfigSchemaCommand() error {
+	parent, err := c.configCommand()
+	if err != nil {
+		return err
+	}
+
+	parent.AddCommand(&cobra.Command{
+		Use:    "schema",
+		Short:  "Print the JSON Schema of the config file",
+		Args:   cobra.NoArgs,
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			schema, err := c.JSONSchema()
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write(schema)
+
+			return err
+		},
+	})
+
+	return nil
+}
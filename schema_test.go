@@ -0,0 +1,157 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type schemaTestSuite struct {
+	suite.Suite
+}
+
+type schemaConfig struct {
+	Name     string        `desc:"Name of the application" default:"app" required:"true"`
+	Token    string        `secret:"true" default:"s3cr3t"`
+	Level    uint8         `default:"3"`
+	Ratio    float64       `default:"0.5"`
+	Enabled  *bool         `desc:"Enable the feature"`
+	Timeout  time.Duration `default:"5s"`
+	Started  time.Time
+	Day      time.Time `layout:"2006-01-02"`
+	Endpoint *url.URL
+	Hosts    []string `default:"a,b"`
+	Labels   map[string]int
+	Server   struct {
+		Port int `desc:"Listening port" default:"8080" required:"true"`
+	}
+	Path string `arg:"0"`
+}
+
+func (s *schemaTestSuite) TestJSONSchema() {
+	cmder, err := New[schemaConfig](nil)
+	s.NoError(err)
+
+	schema, err := cmder.JSONSchema()
+	s.NoError(err)
+
+	s.JSONEq(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "description": "Name of the application", "default": "app"},
+			"token": {"type": "string"},
+			"level": {"type": "integer", "default": 3, "minimum": 0, "maximum": 255},
+			"ratio": {"type": "number", "default": 0.5},
+			"enabled": {"type": ["boolean", "null"], "description": "Enable the feature"},
+			"timeout": {"type": "string", "default": "5s"},
+			"started": {"type": "string", "format": "date-time"},
+			"day": {"type": "string"},
+			"endpoint": {"type": "string"},
+			"hosts": {"type": "array", "items": {"type": "string"}, "default": ["a", "b"]},
+			"labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+			"server": {
+				"type": "object",
+				"properties": {
+					"port": {"type": "integer", "description": "Listening port", "default": 8080}
+				},
+				"required": ["port"]
+			}
+		},
+		"required": ["name"]
+	}`, string(schema))
+}
+
+func (s *schemaTestSuite) TestJSONSchemaWithValidationRules() {
+	cmder, err := New[struct {
+		Level   string         `oneof:"debug info"`
+		Port    int            `min:"1" max:"65535"`
+		Retries uint8          `oneof:"1 3 5"`
+		Timeout time.Duration  `min:"1s"`
+		Name    string         `pattern:"^[a-z]+$" minlen:"2" maxlen:"8"`
+		Hosts   []string       `minlen:"1" oneof:"a b"`
+		Limits  map[string]int `maxlen:"4" max:"10"`
+	}](nil)
+	s.NoError(err)
+
+	schema, err := cmder.JSONSchema()
+	s.NoError(err)
+
+	s.JSONEq(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"level": {"type": "string", "enum": ["debug", "info"]},
+			"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+			"retries": {"type": "integer", "minimum": 0, "maximum": 255, "enum": [1, 3, 5]},
+			"timeout": {"type": "string"},
+			"name": {"type": "string", "pattern": "^[a-z]+$", "minLength": 2, "maxLength": 8},
+			"hosts": {"type": "array", "minItems": 1, "items": {"type": "string", "enum": ["a", "b"]}},
+			"limits": {"type": "object", "maxProperties": 4, "additionalProperties": {"type": "integer", "maximum": 10}}
+		}
+	}`, string(schema))
+}
+
+func (s *schemaTestSuite) TestJSONSchemaWithCommands() {
+	type child struct {
+		Retries int `default:"3"`
+	}
+
+	cmder, err := New[appConfig](nil)
+	s.NoError(err)
+
+	sub, err := New[child](nil)
+	s.NoError(err)
+	s.NoError(cmder.AddCommand("jobs", sub))
+
+	schema, err := cmder.JSONSchema()
+	s.NoError(err)
+
+	s.Contains(string(schema), `"migrate": {`)
+	s.Contains(string(schema), `"jobs": {`)
+	s.Contains(string(schema), `"retries": {`)
+}
+
+func (s *schemaTestSuite) TestConfigSchemaCommand() {
+	var buf bytes.Buffer
+
+	cmder, err := New(func(_ context.Context, _ *schemaConfig) error {
+		return nil
+	}, WithConfigSchema())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&buf)
+	cmder.Cobra().SetArgs([]string{"config", "schema"})
+	s.NoError(cmder.Execute())
+
+	schema, err := cmder.JSONSchema()
+	s.NoError(err)
+	s.Equal(string(schema), buf.String())
+
+	buf.Reset()
+	cmder.Cobra().SetArgs([]string{"--help"})
+	s.NoError(cmder.Execute())
+	s.NotContains(buf.String(), "Manage the config file")
+}
+
+func TestSchemaTestSuite(t *testing.T) {
+	suite.Run(t, new(schemaTestSuite))
+}
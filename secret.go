@@ -0,0 +1,128 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// secretFileSuffix is the suffix of the environment variables naming the file of a secret.
+	secretFileSuffix = "_FILE"
+	// secretFileScheme is the prefix of the values naming the file of a secret.
+	secretFileScheme = "file://"
+	// secretAnnotation marks the flags of the secrets.
+	secretAnnotation = "gocmder_secret"
+)
+
+// flagErrorPattern matches the error of pflag for a flag value that fails to parse.
+var flagErrorPattern = regexp.MustCompile(`^invalid argument ("(?:[^"\\]|\\.)*") for "(?:-[^ ]+, )?--([^"]+)" flag: `)
+
+// secretFile returns the file holding the value of a secret, named by its _FILE
+// environment variable or by a file:// value.
+func (c *Cmder) secretFile(item configItem, raw any) (string, bool) {
+	if !item.isSecret {
+		return "", false
+	}
+
+	if source := c.source(item); source.Kind == SourceEnv && strings.HasSuffix(source.Name, secretFileSuffix) {
+		return cast.ToString(raw), true
+	}
+
+	if value, ok := raw.(string); ok && strings.HasPrefix(value, secretFileScheme) {
+		return strings.TrimPrefix(value, secretFileScheme), true
+	}
+
+	return "", false
+}
+
+// readSecret returns the content of the file of a secret without its trailing newline.
+func (c *Cmder) readSecret(file string) (string, error) {
+	data, err := afero.ReadFile(c.root().fs, file)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSuffix(string(data), "\n")
+
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// redactedError is an error whose message has the secret value redacted.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError replaces the value of a secret in the message of an error.
+func (item configItem) redactError(err error, value any) error {
+	s := cast.ToString(value)
+	if err == nil || !item.isSecret || s == "" {
+		return err
+	}
+
+	return &redactedError{msg: strings.ReplaceAll(err.Error(), s, redacted), err: err}
+}
+
+// redactFlagError is the flag error function of the commands. It redacts the value of a secret
+// flag that fails to parse, and its comma-separated elements for the lists and maps.
+func redactFlagError(cmd *cobra.Command, err error) error {
+	m := flagErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+
+	flag := cmd.Flags().Lookup(m[2])
+	if flag == nil || flag.Annotations[secretAnnotation] == nil {
+		return err
+	}
+
+	value, uerr := strconv.Unquote(m[1])
+	if uerr != nil || value == "" {
+		return err
+	}
+
+	msg := strings.ReplaceAll(err.Error(), value, redacted)
+	for _, elem := range strings.Split(value, ",") {
+		if elem != "" {
+			msg = strings.ReplaceAll(msg, elem, redacted)
+		}
+	}
+
+	return &redactedError{msg: msg, err: err}
+}
+
+// shownValue returns the value of the item as shown in the messages, redacted for the secrets.
+func (item configItem) shownValue(v any) string {
+	if item.isSecret {
+		return redacted
+	}
+
+	return item.formatValue(v)
+}
@@ -0,0 +1,244 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type secretTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type secretConfig struct {
+	User     string `default:"admin"`
+	Password string `desc:"Database password" secret:"true" default:"changeme" minlen:"8"`
+	Pin      int    `secret:"true"`
+}
+
+func (s *secretTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", nil, 0644))
+	s.NoError(afero.WriteFile(s.fs, "/run/secrets/password", []byte("from-a-file\n"), 0600))
+}
+
+func (s *secretTestSuite) execute(args ...string) (*secretConfig, error) {
+	var cfg *secretConfig
+	cmder, err := New(func(_ context.Context, c *secretConfig) error {
+		cfg = c
+		return nil
+	}, WithFS(s.fs), WithPrefix("APP"), WithConfigFile("/config.yaml"), WithConfigMode(ConfigStrict))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return cfg, cmder.Execute()
+}
+
+func (s *secretTestSuite) TestHelpHidesDefault() {
+	_, err := s.execute("--help")
+	s.NoError(err)
+
+	s.Contains(s.buf.String(), `--user string        (default "admin")`)
+	s.Contains(s.buf.String(), "--password string   Database password\n")
+	s.NotContains(s.buf.String(), "changeme")
+}
+
+func (s *secretTestSuite) TestDefault() {
+	cfg, err := s.execute()
+
+	s.NoError(err)
+	s.Equal("changeme", cfg.Password)
+}
+
+func (s *secretTestSuite) TestEnvFile() {
+	s.T().Setenv("APP_PASSWORD_FILE", "/run/secrets/password")
+
+	cfg, err := s.execute()
+	s.NoError(err)
+	s.Equal("from-a-file", cfg.Password)
+
+	// The environment variable holding the value takes precedence.
+	s.T().Setenv("APP_PASSWORD", "from-the-env")
+
+	cfg, err = s.execute()
+	s.NoError(err)
+	s.Equal("from-the-env", cfg.Password)
+}
+
+func (s *secretTestSuite) TestFileValue() {
+	s.NoError(afero.WriteFile(s.fs, "/run/secrets/pin", []byte("1234\r\n"), 0600))
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("password: file:///run/secrets/password\npin: file:///run/secrets/pin\n"), 0644))
+
+	cfg, err := s.execute()
+	s.NoError(err)
+	s.Equal("from-a-file", cfg.Password)
+	s.Equal(1234, cfg.Pin)
+}
+
+func (s *secretTestSuite) TestMissingFile() {
+	s.T().Setenv("APP_PASSWORD_FILE", "/run/secrets/missing")
+
+	cfg, err := s.execute()
+	s.Nil(cfg)
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorContains(err, "password: open /run/secrets/missing")
+}
+
+func (s *secretTestSuite) TestErrorsAreRedacted() {
+	s.NoError(afero.WriteFile(s.fs, "/run/secrets/pin", []byte("12ab"), 0600))
+	s.T().Setenv("APP_PIN_FILE", "/run/secrets/pin")
+
+	_, err := s.execute("--password", "short")
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, `pin: invalid value: strconv.ParseInt: parsing "******": invalid syntax`)
+	s.NotContains(err.Error(), "12ab")
+
+	s.T().Setenv("APP_PIN", "1")
+
+	_, err = s.execute("--password", "short")
+	s.EqualError(err, "password: length must be at least 8, got 5 (flag --password)")
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("pin: 12ab\n"), 0644))
+
+	_, err = s.execute()
+	s.ErrorContains(err, "/config.yaml:1: pin: invalid value:")
+	s.NotContains(err.Error(), "12ab")
+}
+
+func (s *secretTestSuite) TestFlagErrorsAreRedacted() {
+	_, err := s.execute("--pin", "abc12345")
+	s.ErrorIs(err, ErrUsage)
+	s.EqualError(err, `invalid argument "******" for "--pin" flag: strconv.ParseInt: parsing "******": invalid syntax`)
+
+	_, err = s.execute("--user=abc12345", "--pin=abc12345")
+	s.NotContains(err.Error(), "abc12345")
+
+	_, err = s.execute("--password", "secret", "--pin", "abc12345")
+	s.NotContains(err.Error(), "abc12345")
+
+	// The required flags are shadowed on the config commands.
+	cmder, err := New(func(context.Context, *struct {
+		Pin int `secret:"true" required:"true"`
+	}) error {
+		return nil
+	}, WithFS(s.fs), WithConfigInit())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs([]string{"config", "init", "--pin", "abc12345"})
+
+	err = cmder.Execute()
+	s.ErrorIs(err, ErrUsage)
+	s.NotContains(err.Error(), "abc12345")
+}
+
+func (s *secretTestSuite) TestElementErrorsAreRedacted() {
+	type config struct {
+		Pins  []int          `secret:"true"`
+		Ports map[string]int `secret:"true"`
+	}
+
+	execute := func(args ...string) error {
+		cmder, err := New(func(context.Context, *config) error { return nil }, WithFS(s.fs), WithPrefix("APP"))
+		s.NoError(err)
+
+		cmder.Cobra().SetOutput(&s.buf)
+		cmder.Cobra().SetArgs(args)
+
+		return cmder.Execute()
+	}
+
+	s.T().Setenv("APP_PINS", "1234,s3cr3t")
+
+	err := execute()
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, `pins: invalid value: element 1: strconv.ParseInt: parsing "******": invalid syntax`)
+
+	s.T().Setenv("APP_PINS", "")
+	s.T().Setenv("APP_PORTS", "http=s3cr3t")
+
+	err = execute()
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, `ports: invalid value: key "http": strconv.ParseInt: parsing "******": invalid syntax`)
+
+	s.T().Setenv("APP_PORTS", "s3cr3t")
+
+	err = execute()
+	s.ErrorIs(err, ErrConfig)
+	s.NotContains(err.Error(), "s3cr3t")
+
+	s.T().Setenv("APP_PORTS", "")
+
+	err = execute("--pins", "1234,s3cr3t")
+	s.ErrorIs(err, ErrUsage)
+	s.NotContains(err.Error(), "s3cr3t")
+}
+
+func (s *secretTestSuite) TestValidationRules() {
+	type config struct {
+		Token string `secret:"true" pattern:"^tok_[a-z]+$"`
+		Tier  string `secret:"true" oneof:"gold silver"`
+	}
+
+	execute := func(args ...string) error {
+		cmder, err := New(func(context.Context, *config) error { return nil }, WithFS(s.fs))
+		s.NoError(err)
+
+		cmder.Cobra().SetOutput(&s.buf)
+		cmder.Cobra().SetArgs(args)
+
+		return cmder.Execute()
+	}
+
+	s.NoError(execute("--token", "tok_abc", "--tier", "gold"))
+
+	err := execute("--token", "abc", "--tier", "bronze")
+	s.ErrorIs(err, ErrConfig)
+	s.ErrorContains(err, `token: must match ^tok_[a-z]+$, got "******"`)
+	s.ErrorContains(err, `tier: must be one of gold, silver, got "******"`)
+	s.NotContains(err.Error(), "abc")
+	s.NotContains(err.Error(), "bronze")
+}
+
+func (s *secretTestSuite) TestPrintConfig() {
+	var buf bytes.Buffer
+
+	cmder, err := New(func(_ context.Context, _ *secretConfig) error {
+		return nil
+	}, WithFS(s.fs), WithPrintConfig())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&buf)
+	cmder.Cobra().SetArgs([]string{"--print-config", "--password", "hunter22"})
+	s.NoError(cmder.Execute())
+
+	s.Contains(buf.String(), "password: '******'")
+	s.NotContains(buf.String(), "hunter22")
+}
+
+func TestSecretTestSuite(t *testing.T) {
+	suite.Run(t, new(secretTestSuite))
+}
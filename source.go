@@ -0,0 +1,303 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const explainConfigFlagName = "explain-config"
+
+// SourceKind identifies where the value of a config key comes from.
+type SourceKind int
+
+const (
+	// SourceNone means that nothing sets the key, it keeps the zero value of its type.
+	SourceNone SourceKind = iota
+	// SourceDefault means that the value comes from the `default` tag.
+	SourceDefault
+	// SourceFile means that the value comes from a config file.
+	SourceFile
+	// SourceEnv means that the value comes from an environment variable.
+	SourceEnv
+	// SourceFlag means that the value comes from a command-line flag.
+	SourceFlag
+	// SourceArg means that the value comes from a positional argument.
+	SourceArg
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	case SourceArg:
+		return "argument"
+	default:
+		return "none"
+	}
+}
+
+// Source describes where the value of a config key comes from.
+type Source struct {
+	Kind SourceKind
+	// Name is the name of the flag, of the environment variable or of the argument.
+	Name string
+	// File is the config file setting the key and Line its line, 0 when unknown.
+	File string
+	Line int
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFlag:
+		return "flag --" + s.Name
+	case SourceEnv:
+		return "env " + s.Name
+	case SourceArg:
+		return "argument <" + s.Name + ">"
+	case SourceFile:
+		if s.Line > 0 {
+			return fmt.Sprintf("file %s:%d", s.File, s.Line)
+		}
+		return "file " + s.File
+	default:
+		return s.Kind.String()
+	}
+}
+
+// Source returns where the value of a config key comes from, following the precedence
+// flags > environment variables > config files > defaults. The key is the Viper key,
+// e.g. "server.port". It returns false when the key is unknown. The sources are known
+// once the command is executed.
+func (c *Cmder) Source(key string) (Source, bool) {
+	root := c.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	return c.lookupSource(strings.ToLower(key))
+}
+
+func (c *Cmder) lookupSource(key string) (Source, bool) {
+	for _, item := range c.items {
+		if item.arg == "" && c.key(item) == key {
+			return c.source(item), true
+		}
+	}
+
+	for _, child := range c.children {
+		if source, ok := child.lookupSource(key); ok {
+			return source, true
+		}
+	}
+
+	return Source{}, false
+}
+
+func (c *Cmder) source(item configItem) Source {
+	// The deprecated flags of the old keys mark the flag of the item as changed.
+	flags := c.commands[item.command].PersistentFlags()
+	for _, name := range append(item.aliasFlagNames(), item.flagName()) {
+		if f := flags.Lookup(name); f != nil && f.Changed {
+			return Source{Kind: SourceFlag, Name: name}
+		}
+	}
+
+	for _, env := range c.envNames(item) {
+		if os.Getenv(env) != "" {
+			return Source{Kind: SourceEnv, Name: env}
+		}
+	}
+
+	if source, ok := c.root().fileSources[c.key(item)]; ok {
+		return source
+	}
+
+	if item.hasDefaultValue {
+		return Source{Kind: SourceDefault}
+	}
+
+	return Source{}
+}
+
+// recordFileSources records the file as the source of its keys, including the sections.
+func (c *Cmder) recordFileSources(file string, settings map[string]any, lines map[string]int, prefix string) {
+	for k, value := range settings {
+		key := prefix + k
+		c.fileSources[key] = Source{Kind: SourceFile, File: file, Line: lines[key]}
+
+		if m, ok := value.(map[string]any); ok {
+			c.recordFileSources(file, m, lines, key+".")
+		}
+	}
+}
+
+// keyLines returns the line of every key of a YAML, JSON or TOML config file.
+// The lines of the other formats are unknown.
+func keyLines(file string, data []byte) map[string]int {
+	lines := make(map[string]int)
+
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(file), ".")) {
+	case "yaml", "yml", "json":
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+			yamlKeyLines(doc.Content[0], "", lines)
+		}
+	case "toml":
+		tomlKeyLines(data, lines)
+	}
+
+	return lines
+}
+
+func yamlKeyLines(node *yaml.Node, prefix string, lines map[string]int) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := prefix + strings.ToLower(node.Content[i].Value)
+		lines[key] = node.Content[i].Line
+		yamlKeyLines(node.Content[i+1], key+".", lines)
+	}
+}
+
+func tomlKeyLines(data []byte, lines map[string]int) {
+	line := func(n *unstable.Node) int {
+		return bytes.Count(data[:n.Raw.Offset], []byte("\n")) + 1
+	}
+
+	var keyValue func(n *unstable.Node, prefix string)
+	keyValue = func(n *unstable.Node, prefix string) {
+		key, first := tomlKey(n)
+		key = prefix + key
+		lines[key] = line(first)
+
+		if value := n.Value(); value.Kind == unstable.InlineTable {
+			for it := value.Children(); it.Next(); {
+				keyValue(it.Node(), key+".")
+			}
+		}
+	}
+
+	p := unstable.Parser{}
+	p.Reset(data)
+
+	table := ""
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			key, first := tomlKey(expr)
+			table = key + "."
+			if _, ok := lines[key]; !ok {
+				lines[key] = line(first)
+			}
+		case unstable.KeyValue:
+			keyValue(expr, table)
+		}
+	}
+}
+
+// tomlKey returns the dotted key of a TOML table or key-value node and its first part.
+func tomlKey(n *unstable.Node) (string, *unstable.Node) {
+	var parts []string
+	var first *unstable.Node
+
+	for it := n.Key(); it.Next(); {
+		if first == nil {
+			first = it.Node()
+		}
+		parts = append(parts, strings.ToLower(string(it.Node().Data)))
+	}
+
+	return strings.Join(parts, "."), first
+}
+
+// addExplainConfigFlag adds the persistent --explain-config flag.
+func (c *Cmder) addExplainConfigFlag() error {
+	if c.cobra.PersistentFlags().Lookup(explainConfigFlagName) != nil {
+		return fmt.Errorf("flag %q is reserved to explain the config", explainConfigFlagName)
+	}
+
+	c.cobra.PersistentFlags().Bool(explainConfigFlagName, false, "print the value and the source of every config key and exit")
+
+	return nil
+}
+
+// explainRequested reports whether --explain-config is set for the command.
+func explainRequested(cmd *cobra.Command) bool {
+	f := cmd.Flags().Lookup(explainConfigFlagName)
+	return f != nil && f.Value.String() == "true"
+}
+
+// explain writes the key, the effective value and the source of the config items
+// of the command and of its parents.
+func (c *Cmder) explain(w io.Writer, command string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+
+	for _, item := range c.items {
+		if item.arg != "" || !item.inCommand(command) {
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.key(item), c.effectiveValue(item), c.source(item))
+	}
+
+	return tw.Flush()
+}
+
+// effectiveValue returns the string form of the value resolved by Viper for an item.
+// The secret values are redacted.
+func (c *Cmder) effectiveValue(item configItem) string {
+	key := c.key(item)
+
+	if item.optional && !c.viper.IsSet(key) {
+		return ""
+	}
+
+	if item.isSecret {
+		return redacted
+	}
+
+	raw := c.viper.Get(key)
+	if raw == nil {
+		return ""
+	}
+
+	value, err := item.decode(raw)
+	if err != nil {
+		return fmt.Sprint(raw)
+	}
+
+	return item.formatValue(value)
+}
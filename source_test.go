@@ -0,0 +1,170 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type sourceTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type sourceConfig struct {
+	Name   string
+	Port   int `default:"8080"`
+	Debug  bool
+	Server struct {
+		Host    string
+		Timeout int `default:"30"`
+	}
+}
+
+func (s *sourceTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+}
+
+func (s *sourceTestSuite) newCmder(run *bool, opts ...CmderOption) *Cmder {
+	cmder, err := New(func(_ context.Context, _ *sourceConfig) error {
+		*run = true
+		return nil
+	}, append([]CmderOption{WithFS(s.fs), WithPrefix("APP")}, opts...)...)
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+
+	return cmder
+}
+
+func (s *sourceTestSuite) TestSource() {
+	s.NoError(afero.WriteFile(s.fs, "/base.yaml", []byte("name: base\nserver:\n  host: base.local\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/app.toml", []byte("# app\n[server]\nhost = \"app.local\"\ntimeout = 10\n"), 0644))
+
+	s.T().Setenv("APP_DEBUG", "true")
+
+	var run bool
+	cmder := s.newCmder(&run, WithConfigFiles("/base.yaml"), WithConfigFile("/app.toml"))
+	cmder.Cobra().SetArgs([]string{"--name", "flag"})
+	s.NoError(cmder.Execute())
+	s.True(run)
+
+	tests := map[string]Source{
+		"name":           {Kind: SourceFlag, Name: "name"},
+		"debug":          {Kind: SourceEnv, Name: "APP_DEBUG"},
+		"server.host":    {Kind: SourceFile, File: "/app.toml", Line: 3},
+		"Server.Timeout": {Kind: SourceFile, File: "/app.toml", Line: 4},
+		"port":           {Kind: SourceDefault},
+	}
+
+	for key, expected := range tests {
+		source, ok := cmder.Source(key)
+		s.True(ok, key)
+		s.Equal(expected, source, key)
+	}
+
+	_, ok := cmder.Source("missing")
+	s.False(ok)
+}
+
+func (s *sourceTestSuite) TestSourceWithLayeredFiles() {
+	s.NoError(afero.WriteFile(s.fs, "/base.json", []byte("{\n  \"name\": \"base\",\n  \"port\": 80\n}\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/site.yaml", []byte("port: 81\n"), 0644))
+
+	var run bool
+	cmder := s.newCmder(&run, WithConfigFiles("/base.json", "/site.yaml"))
+	cmder.Cobra().SetArgs([]string{})
+	s.NoError(cmder.Execute())
+
+	source, _ := cmder.Source("name")
+	s.Equal("file /base.json:2", source.String())
+
+	source, _ = cmder.Source("port")
+	s.Equal("file /site.yaml:1", source.String())
+
+	source, _ = cmder.Source("server.host")
+	s.Equal("none", source.String())
+}
+
+func (s *sourceTestSuite) TestExplainConfig() {
+	s.NoError(afero.WriteFile(s.fs, "/app.yaml", []byte("server:\n  host: app.local\n"), 0644))
+
+	s.T().Setenv("APP_PORT", "9090")
+
+	var run bool
+	cmder := s.newCmder(&run, WithConfigFile("/app.yaml"), WithExplainConfig())
+	cmder.Cobra().SetArgs([]string{"--explain-config", "--debug"})
+	s.NoError(cmder.Execute())
+	s.False(run)
+
+	s.Equal(`KEY             VALUE      SOURCE
+name                       none
+port            9090       env APP_PORT
+debug           true       flag --debug
+server.host     app.local  file /app.yaml:2
+server.timeout  30         default
+`, s.buf.String())
+}
+
+func (s *sourceTestSuite) TestExplainConfigOfGroupCommand() {
+	type config struct {
+		Name  string `default:"app"`
+		Serve struct {
+			Port int `default:"8080"`
+		} `cmd:"serve"`
+	}
+
+	cmder, err := New[config](nil, WithFS(s.fs), WithExplainConfig())
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs([]string{"serve", "--explain-config", "--port", "9090"})
+
+	s.NoError(cmder.Execute())
+	s.Equal(`KEY         VALUE  SOURCE
+name        app    default
+serve.port  9090   flag --port
+`, s.buf.String())
+}
+
+func (s *sourceTestSuite) TestExplainConfigIsOptIn() {
+	var run bool
+	cmder := s.newCmder(&run)
+	cmder.Cobra().SetArgs([]string{"--explain-config"})
+
+	s.ErrorIs(cmder.Execute(), ErrUsage)
+	s.False(run)
+}
+
+func (s *sourceTestSuite) TestKeyLines() {
+	s.Equal(map[string]int{"server": 1, "server.host": 2, "server.ports": 3},
+		keyLines("app.yml", []byte("server:\n  host: local\n  ports: [1, 2]\n")))
+
+	s.Equal(map[string]int{"name": 1, "db": 2, "db.port": 3, "db.user": 4, "db.user.name": 4, "db.log.level": 5},
+		keyLines("app.toml", []byte("name = \"app\"\n[db]\nport = 1\nuser = { name = \"me\" }\nlog.level = \"info\"\n")))
+
+	s.Empty(keyLines("app.env", []byte("NAME=app\n")))
+}
+
+func TestSourceTestSuite(t *testing.T) {
+	suite.Run(t, new(sourceTestSuite))
+}
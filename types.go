@@ -0,0 +1,121 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+type parseFunc func(value string) (any, error)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*pflag.Value)(nil)).Elem()
+
+	registryMu sync.RWMutex
+	registry   = map[reflect.Type]parseFunc{}
+)
+
+func init() {
+	RegisterType(url.Parse)
+}
+
+// RegisterType registers a parser for the config fields of type V.
+// The parser receives the string from the flag, the environment variable, the config file
+// or the default tag. This is useful for third-party types that don't implement
+// encoding.TextUnmarshaler. Registered types take precedence over the built-in conversions.
+func RegisterType[V any](parse func(value string) (V, error)) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[reflect.TypeOf((*V)(nil)).Elem()] = func(value string) (any, error) {
+		return parse(value)
+	}
+}
+
+// textParser returns the parser of a type registered with RegisterType or
+// implementing encoding.TextUnmarshaler or pflag.Value, either on the type itself
+// or on a pointer to it.
+func textParser(t reflect.Type) (parseFunc, bool) {
+	registryMu.RLock()
+	parse, ok := registry[t]
+	registryMu.RUnlock()
+
+	if ok {
+		return parse, true
+	}
+
+	if t.Kind() == reflect.Pointer && (t.Implements(textUnmarshalerType) || t.Implements(flagValueType)) {
+		return func(value string) (any, error) {
+			v := reflect.New(t.Elem())
+			if err := unmarshalText(v, value); err != nil {
+				return nil, err
+			}
+			return v.Interface(), nil
+		}, true
+	}
+
+	if pt := reflect.PointerTo(t); pt.Implements(textUnmarshalerType) || pt.Implements(flagValueType) {
+		return func(value string) (any, error) {
+			v := reflect.New(t)
+			if err := unmarshalText(v, value); err != nil {
+				return nil, err
+			}
+			return v.Elem().Interface(), nil
+		}, true
+	}
+
+	return nil, false
+}
+
+func unmarshalText(v reflect.Value, value string) error {
+	if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	return v.Interface().(pflag.Value).Set(value)
+}
+
+// formatText returns the string form of a value parsed by a textParser.
+func formatText(value any) string {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return ""
+	}
+
+	if rv.Kind() != reflect.Pointer {
+		// Use a pointer to a copy so that methods with a pointer receiver are found.
+		pv := reflect.New(rv.Type())
+		pv.Elem().Set(rv)
+		rv = pv
+	}
+
+	switch v := rv.Interface().(type) {
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprint(value)
+}
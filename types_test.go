@@ -0,0 +1,163 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type typesTestSuite struct {
+	suite.Suite
+}
+
+func (s *typesTestSuite) TestTextParserWithTextUnmarshaler() {
+	parse, ok := textParser(reflect.TypeOf(netip.Prefix{}))
+	s.True(ok)
+
+	value, err := parse("10.0.0.0/8")
+	s.NoError(err)
+	s.Equal(netip.MustParsePrefix("10.0.0.0/8"), value)
+	s.Equal("10.0.0.0/8", formatText(value))
+
+	_, err = parse("nope")
+	s.Error(err)
+}
+
+func (s *typesTestSuite) TestTextParserWithPointerType() {
+	parse, ok := textParser(reflect.TypeOf(&byteSize{}))
+	s.True(ok)
+
+	value, err := parse("2KB")
+	s.NoError(err)
+	s.Equal(&byteSize{bytes: 2048}, value)
+	s.Equal("2048", formatText(value))
+}
+
+func (s *typesTestSuite) TestTextParserWithFlagValue() {
+	parse, ok := textParser(reflect.TypeOf(color("")))
+	s.True(ok)
+
+	value, err := parse("RED")
+	s.NoError(err)
+	s.Equal(color("red"), value)
+	s.Equal("red", formatText(value))
+}
+
+func (s *typesTestSuite) TestTextParserWithBuiltinURL() {
+	parse, ok := textParser(reflect.TypeOf(&url.URL{}))
+	s.True(ok)
+
+	value, err := parse("https://example.com/path")
+	s.NoError(err)
+	s.Equal("https://example.com/path", formatText(value))
+}
+
+func (s *typesTestSuite) TestRegisterType() {
+	_, ok := textParser(reflect.TypeOf(point{}))
+	s.False(ok)
+
+	RegisterType(parsePoint)
+	s.T().Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+
+		delete(registry, reflect.TypeOf(point{}))
+	})
+
+	parse, ok := textParser(reflect.TypeOf(point{}))
+	s.True(ok)
+
+	value, err := parse("1:2")
+	s.NoError(err)
+	s.Equal(point{x: 1, y: 2}, value)
+	s.Equal("{1 2}", formatText(value))
+}
+
+func (s *typesTestSuite) TestTextParserWithUnsupportedType() {
+	_, ok := textParser(reflect.TypeOf(0))
+	s.False(ok)
+
+	_, ok = textParser(reflect.TypeOf(net.IPNet{}))
+	s.False(ok)
+}
+
+func TestTypesTestSuite(t *testing.T) {
+	suite.Run(t, new(typesTestSuite))
+}
+
+// byteSize implements encoding.TextUnmarshaler and encoding.TextMarshaler.
+type byteSize struct {
+	bytes int
+}
+
+func (b *byteSize) UnmarshalText(text []byte) error {
+	value := strings.TrimSuffix(string(text), "KB")
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	if value != string(text) {
+		n *= 1024
+	}
+
+	b.bytes = n
+	return nil
+}
+
+func (b byteSize) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(b.bytes)), nil
+}
+
+// color implements pflag.Value.
+type color string
+
+func (c *color) Set(value string) error {
+	switch v := strings.ToLower(value); v {
+	case "red", "green", "blue":
+		*c = color(v)
+		return nil
+	default:
+		return fmt.Errorf("unknown color %q", value)
+	}
+}
+
+func (c *color) String() string {
+	return string(*c)
+}
+
+func (c *color) Type() string {
+	return "color"
+}
+
+// point is registered with RegisterType.
+type point struct {
+	x, y int
+}
+
+func parsePoint(value string) (point, error) {
+	var p point
+	_, err := fmt.Sscanf(value, "%d:%d", &p.x, &p.y)
+	return p, err
+}
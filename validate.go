@@ -0,0 +1,342 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minKey     = "min"
+	maxKey     = "max"
+	oneofKey   = "oneof"
+	patternKey = "pattern"
+	minLenKey  = "minlen"
+	maxLenKey  = "maxlen"
+	nonzeroKey = "nonzero"
+)
+
+// Validator is implemented by the config structs, and their sections, with checks
+// spanning several fields. Validate is called after the values are decoded and pass
+// the validation tags.
+type Validator interface {
+	Validate() error
+}
+
+// validationRules are the checks of the validation tags of an item. The min, max, oneof
+// and pattern checks apply to the elements of the slices and maps.
+type validationRules struct {
+	min, max       *float64
+	minTag, maxTag string
+	oneof          []string
+	pattern        *regexp.Regexp
+	minLen, maxLen *int
+	nonzero        bool
+}
+
+func (r validationRules) empty() bool {
+	return r.min == nil && r.max == nil && r.oneof == nil && r.pattern == nil &&
+		r.minLen == nil && r.maxLen == nil && !r.nonzero
+}
+
+// newValidationRules parses the validation tags of an item.
+func newValidationRules(item configItem, sf reflect.StructField) (validationRules, error) {
+	var rules validationRules
+	tag := sf.Tag
+	scalar := item.scalar()
+
+	for _, bound := range []struct {
+		key   string
+		value **float64
+		tag   *string
+	}{{minKey, &rules.min, &rules.minTag}, {maxKey, &rules.max, &rules.maxTag}} {
+		value, ok := tag.Lookup(bound.key)
+		if !ok {
+			continue
+		}
+
+		if !scalar.isNumber() {
+			return rules, fmt.Errorf("%s: %s requires a number", item.name, bound.key)
+		}
+
+		f, err := scalar.parseNumber(value)
+		if err != nil {
+			return rules, fmt.Errorf("%s: invalid %s: %w", item.name, bound.key, err)
+		}
+
+		*bound.value, *bound.tag = &f, value
+	}
+
+	oneof, err := item.enumValues(sf)
+	if err != nil {
+		return rules, err
+	}
+	rules.oneof = oneof
+
+	if value, ok := tag.Lookup(patternKey); ok {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return rules, fmt.Errorf("%s: invalid pattern: %w", item.name, err)
+		}
+		rules.pattern = pattern
+	}
+
+	for _, length := range []struct {
+		key   string
+		value **int
+	}{{minLenKey, &rules.minLen}, {maxLenKey, &rules.maxLen}} {
+		value, ok := tag.Lookup(length.key)
+		if !ok {
+			continue
+		}
+
+		if item.kind != reflect.String && item.kind != reflect.Slice && item.kind != reflect.Map {
+			return rules, fmt.Errorf("%s: %s requires a string, a slice or a map", item.name, length.key)
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return rules, fmt.Errorf("%s: invalid %s %q", item.name, length.key, value)
+		}
+		*length.value = &n
+	}
+
+	rules.nonzero, _ = strconv.ParseBool(tag.Get(nonzeroKey))
+
+	return rules, nil
+}
+
+// scalar returns the element item of the slices and maps, or the item itself.
+func (item configItem) scalar() configItem {
+	if item.elem != nil {
+		return *item.elem
+	}
+
+	return item
+}
+
+func (item configItem) isNumber() bool {
+	switch item.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return item.parser == nil
+	}
+
+	return false
+}
+
+// parseNumber parses a bound of a number, or of a duration.
+func (item configItem) parseNumber(value string) (float64, error) {
+	if item.typ == durationType {
+		d, err := time.ParseDuration(value)
+		return float64(d), err
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// validate checks the value of the item against its rules. An invalid
+// reflect.Value means that the field, or one of its sections, is a nil pointer.
+func (item configItem) validate(v reflect.Value) error {
+	rules := item.rules
+
+	if !v.IsValid() {
+		if rules.nonzero {
+			return errors.New("must be set")
+		}
+		return nil
+	}
+
+	if rules.nonzero {
+		switch item.kind {
+		case reflect.String, reflect.Slice, reflect.Map:
+			if v.Len() == 0 {
+				return errors.New("must not be empty")
+			}
+		default:
+			if v.IsZero() {
+				return errors.New("must not be zero")
+			}
+		}
+	}
+
+	if rules.minLen != nil || rules.maxLen != nil {
+		n := v.Len()
+		if item.kind == reflect.String {
+			n = utf8.RuneCountInString(v.String())
+		}
+
+		if rules.minLen != nil && n < *rules.minLen {
+			return fmt.Errorf("length must be at least %d, got %d", *rules.minLen, n)
+		}
+		if rules.maxLen != nil && n > *rules.maxLen {
+			return fmt.Errorf("length must be at most %d, got %d", *rules.maxLen, n)
+		}
+	}
+
+	switch {
+	case item.kind == reflect.Slice && item.elem != nil:
+		for i := 0; i < v.Len(); i++ {
+			if err := item.elem.validateScalar(rules, v.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case item.kind == reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			if err := item.elem.validateScalar(rules, iter.Value()); err != nil {
+				return fmt.Errorf("[%s]: %w", iter.Key(), err)
+			}
+		}
+	default:
+		return item.validateScalar(rules, v)
+	}
+
+	return nil
+}
+
+// validateScalar checks a scalar value against the min, max, oneof and pattern rules.
+func (item configItem) validateScalar(rules validationRules, v reflect.Value) error {
+	if rules.min != nil || rules.max != nil {
+		var f float64
+
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = v.Float()
+		}
+
+		if rules.min != nil && f < *rules.min {
+			return fmt.Errorf("must be at least %s, got %s", rules.minTag, item.shownValue(item.convert(v).Interface()))
+		}
+		if rules.max != nil && f > *rules.max {
+			return fmt.Errorf("must be at most %s, got %s", rules.maxTag, item.shownValue(item.convert(v).Interface()))
+		}
+	}
+
+	if rules.oneof == nil && rules.pattern == nil {
+		return nil
+	}
+
+	// The secrets are matched on their value and only redacted in the message.
+	value := item.convert(v).Interface()
+	formatted := item.formatValue(value)
+
+	if rules.oneof != nil && !contains(rules.oneof, formatted) {
+		return fmt.Errorf("must be one of %s, got %q", strings.Join(rules.oneof, ", "), item.shownValue(value))
+	}
+
+	if rules.pattern != nil && !rules.pattern.MatchString(formatted) {
+		return fmt.Errorf("must match %s, got %q", rules.pattern, item.shownValue(value))
+	}
+
+	return nil
+}
+
+// validate checks the values of the config struct cfg for the command and its parents against
+// their validation tags and returns every failure, with the source of the value.
+func (c *Cmder) validate(cfg reflect.Value, command string, args []string) error {
+	pargs := c.args[command]
+
+	var errs []error
+
+	for _, item := range c.items {
+		if item.rules.empty() || !item.inCommand(command) || item.arg != "" && item.command != command {
+			continue
+		}
+
+		field, _ := item.fieldValue(cfg)
+		if err := item.validate(field); err != nil {
+			source := c.source(item)
+			if item.arg != "" {
+				source = pargs.source(item, args)
+			}
+
+			errs = append(errs, &FieldError{Key: c.key(item), Source: source, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return errors.Join(c.callValidators(cfg, section{}, command)...)
+}
+
+// callValidators calls Validate on the sections of the struct v in the scope of the
+// command, depth-first, then on v itself unless one of its sections failed. The errors
+// of the sections are prefixed by their key.
+func (c *Cmder) callValidators(v reflect.Value, sec section, command string) []error {
+	var errs []error
+
+	for _, vf := range reflect.VisibleFields(v.Type()) {
+		if !isSection(vf.Type) || len(vf.Index) > 1 || isSkipped(vf) {
+			continue
+		}
+
+		child := sec.child(vf)
+		if child.command != "" && command != child.command && !strings.HasPrefix(command, child.command+".") {
+			continue
+		}
+
+		field := v.Field(vf.Index[0])
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+
+		errs = append(errs, c.callValidators(field, child, command)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	validator, ok := v.Addr().Interface().(Validator)
+	if !ok {
+		return nil
+	}
+
+	if err := validator.Validate(); err != nil {
+		if key := strings.TrimSuffix(c.keyPrefix+sec.prefix, "."); key != "" {
+			err = &FieldError{Key: key, Err: err}
+		}
+		return []error{err}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,233 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type validateTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type validatedConfig struct {
+	Level   string        `default:"info" oneof:"debug info warn error"`
+	Name    string        `pattern:"^[a-z]+$" default:"app"`
+	Token   string        `minlen:"4" maxlen:"8" default:"abcd"`
+	Timeout time.Duration `min:"1s" max:"1m" default:"5s"`
+	Ratio   float64       `min:"0" max:"1"`
+	Owner   *string       `nonzero:"true"`
+	Hosts   []string      `maxlen:"2" pattern:"^[a-z.]+$"`
+	Server  struct {
+		Port int `min:"1" max:"65535" default:"8080"`
+	}
+	File string `arg:"0" default:"a.txt" pattern:"\\.txt$"`
+}
+
+func (s *validateTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", nil, 0644))
+}
+
+func (s *validateTestSuite) execute(args ...string) (bool, error) {
+	var run bool
+	cmder, err := New(func(_ context.Context, _ *validatedConfig) error {
+		run = true
+		return nil
+	}, WithFS(s.fs), WithPrefix("APP"), WithConfigFile("/config.yaml"))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return run, cmder.Execute()
+}
+
+func (s *validateTestSuite) TestValid() {
+	run, err := s.execute("--owner", "me", "--hosts", "a.local,b.local", "--ratio", "0.5", "b.txt")
+
+	s.NoError(err)
+	s.True(run)
+}
+
+func (s *validateTestSuite) TestInvalid() {
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("level: verbose\nserver:\n  port: 70000\n"), 0644))
+	s.T().Setenv("APP_TIMEOUT", "2m")
+
+	run, err := s.execute("--name", "App1", "--token", "abc", "--ratio", "1.5", "--hosts", "a,B,c", "b.md")
+
+	s.False(run)
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, `level: must be one of debug, info, warn, error, got "verbose" (file /config.yaml:1)
+name: must match ^[a-z]+$, got "App1" (flag --name)
+token: length must be at least 4, got 3 (flag --token)
+timeout: must be at most 1m, got 2m0s (env APP_TIMEOUT)
+ratio: must be at most 1, got 1.5 (flag --ratio)
+owner: must be set
+hosts: length must be at most 2, got 3 (flag --hosts)
+server.port: must be at most 65535, got 70000 (file /config.yaml:3)
+file: must match \.txt$, got "b.md" (argument <file>)`)
+
+	var fieldErr *FieldError
+	s.True(errors.As(err, &fieldErr))
+	s.Equal("level", fieldErr.Key)
+	s.Equal(Source{Kind: SourceFile, File: "/config.yaml", Line: 1}, fieldErr.Source)
+}
+
+func (s *validateTestSuite) TestInvalidElements() {
+	run, err := s.execute("--owner", "me", "--hosts", "a,B")
+
+	s.False(run)
+	s.EqualError(err, `hosts: [1]: must match ^[a-z.]+$, got "B" (flag --hosts)`)
+}
+
+func (s *validateTestSuite) TestNonzero() {
+	type config struct {
+		Name  string         `nonzero:"true"`
+		Count int            `nonzero:"true"`
+		Tags  map[string]int `nonzero:"true"`
+	}
+
+	items, err := createConfigItems(config{})
+	s.NoError(err)
+
+	s.EqualError(items[0].validate(reflect.ValueOf("")), "must not be empty")
+	s.EqualError(items[1].validate(reflect.ValueOf(0)), "must not be zero")
+	s.EqualError(items[2].validate(reflect.ValueOf(map[string]int{})), "must not be empty")
+	s.NoError(items[1].validate(reflect.ValueOf(1)))
+}
+
+func (s *validateTestSuite) TestInvalidTags() {
+	_, err := createConfigItems(struct {
+		Name string `min:"1"`
+	}{})
+	s.EqualError(err, "name: min requires a number")
+
+	_, err = createConfigItems(struct {
+		Port int `max:"high"`
+	}{})
+	s.EqualError(err, `port: invalid max: strconv.ParseFloat: parsing "high": invalid syntax`)
+
+	_, err = createConfigItems(struct {
+		Name string `pattern:"["`
+	}{})
+	s.EqualError(err, "name: invalid pattern: error parsing regexp: missing closing ]: `[`")
+
+	_, err = createConfigItems(struct {
+		Port int `minlen:"1"`
+	}{})
+	s.EqualError(err, "port: minlen requires a string, a slice or a map")
+
+	_, err = createConfigItems(struct {
+		Name string `maxlen:"-1"`
+	}{})
+	s.EqualError(err, `name: invalid maxlen "-1"`)
+}
+
+type tlsConfig struct {
+	Cert string
+	Key  string
+}
+
+func (c tlsConfig) Validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return errors.New("cert and key must be set together")
+	}
+	return nil
+}
+
+type workersConfig struct {
+	MinWorkers int `default:"1"`
+	MaxWorkers int `default:"4"`
+	Server     struct {
+		TLS *tlsConfig
+	}
+	Migrate struct {
+		TLS tlsConfig
+	} `cmd:"migrate"`
+}
+
+func (c *workersConfig) Validate() error {
+	if c.MinWorkers > c.MaxWorkers {
+		return fmt.Errorf("min_workers %d is greater than max_workers %d", c.MinWorkers, c.MaxWorkers)
+	}
+	return nil
+}
+
+func (s *validateTestSuite) executeWorkers(args ...string) (bool, error) {
+	var run bool
+	handler := func(_ context.Context, _ *workersConfig) error {
+		run = true
+		return nil
+	}
+
+	cmder, err := New(handler, WithFS(s.fs), WithConfigFile("/config.yaml"), WithCommand("migrate", handler))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs(args)
+
+	return run, cmder.Execute()
+}
+
+func (s *validateTestSuite) TestValidator() {
+	run, err := s.executeWorkers("--min-workers", "8")
+	s.False(run)
+	s.ErrorIs(err, ErrConfig)
+	s.EqualError(err, "min_workers 8 is greater than max_workers 4")
+
+	run, err = s.executeWorkers("--min-workers", "2")
+	s.True(run)
+	s.NoError(err)
+}
+
+func (s *validateTestSuite) TestValidatorSections() {
+	run, err := s.executeWorkers("--min-workers", "8", "--server-tls-cert", "cert.pem")
+	s.False(run)
+	s.EqualError(err, "server.tls: cert and key must be set together")
+
+	var fieldErr *FieldError
+	s.True(errors.As(err, &fieldErr))
+	s.Equal("server.tls", fieldErr.Key)
+
+	// The sections of a command are only validated when it runs.
+
+	run, err = s.executeWorkers("migrate", "--tls-key", "key.pem")
+	s.False(run)
+	s.EqualError(err, "migrate.tls: cert and key must be set together")
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("migrate:\n  tls:\n    key: key.pem\n"), 0644))
+
+	run, err = s.executeWorkers()
+	s.NoError(err)
+	s.True(run)
+}
+
+func TestValidateTestSuite(t *testing.T) {
+	suite.Run(t, new(validateTestSuite))
+}
@@ -0,0 +1,359 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// watcher reloads the config of a Cmder when its config file changes.
+type watcher struct {
+	cfgType  reflect.Type
+	onChange func(old, new any)
+
+	// mu guards current, the last valid config.
+	mu      sync.RWMutex
+	current any
+
+	// reloading serializes the reloads.
+	reloading sync.Mutex
+}
+
+// Config returns the current config of the Cmder, or nil when T is not its config type.
+// With WithWatch, it is the last valid config reloaded from the config file and it is
+// safe to call from any goroutine.
+func Config[T any](c *Cmder) *T {
+	if c.watch == nil {
+		cfg, _ := c.cfg.(*T)
+		return cfg
+	}
+
+	c.watch.mu.RLock()
+	defer c.watch.mu.RUnlock()
+
+	cfg, _ := c.watch.current.(*T)
+
+	return cfg
+}
+
+// initWatch checks the config type of the WithWatch callback.
+func (c *Cmder) initWatch() error {
+	if c.watch == nil {
+		return nil
+	}
+
+	if cfgType := reflect.TypeOf(c.cfg); c.watch.cfgType != cfgType {
+		return fmt.Errorf("watch expects config type %s, got %s", c.watch.cfgType, cfgType)
+	}
+
+	c.watch.current = c.cfg
+
+	return nil
+}
+
+// watchInterval is the interval at which the config files are polled on the file
+// systems other than the OS one.
+var watchInterval = time.Second
+
+// startWatch watches the config files while the command runs and returns the function
+// stopping the watch. A change that fails to load or to validate is reported and the
+// current config is kept.
+func (c *Cmder) startWatch(cmd *cobra.Command, command string, args []string) (func(), error) {
+	root := c.root()
+
+	files := root.configFiles
+	if used := root.viper.ConfigFileUsed(); used != "" {
+		files = append(files[:len(files):len(files)], used)
+	}
+
+	if len(files) == 0 {
+		return func() {}, nil
+	}
+
+	onChange := func() {
+		changed, err := c.reload(cmd, command, args)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: config not reloaded: %v\n", err)
+			return
+		}
+
+		if len(changed) > 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "config reloaded, changed keys: %s\n", strings.Join(changed, ", "))
+		}
+	}
+
+	// fsnotify only sees the files of the OS, the other file systems are polled.
+	if _, ok := root.fs.(*afero.OsFs); ok {
+		return notifyChanges(files, onChange)
+	}
+
+	return pollChanges(root.fs, files, onChange), nil
+}
+
+// notifyChanges calls onChange when one of the files is written, created, removed or renamed.
+// The directories are watched so that a missing file is seen once it is created.
+func notifyChanges(files []string, onChange func()) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[string]bool)
+	for _, file := range files {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+
+		watched[file] = true
+
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if watched[filepath.Clean(event.Name)] && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+					onChange()
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		watcher.Close()
+		<-done
+	}, nil
+}
+
+// fileState is what the polling compares to detect that a file changed.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime int64
+}
+
+// pollChanges calls onChange when the size, the modification time or the existence of one
+// of the files changes, checking them every watchInterval.
+func pollChanges(fs afero.Fs, files []string, onChange func()) func() {
+	states := func() []fileState {
+		states := make([]fileState, len(files))
+		for i, file := range files {
+			if info, err := fs.Stat(file); err == nil {
+				states[i] = fileState{exists: true, size: info.Size(), modTime: info.ModTime().UnixNano()}
+			}
+		}
+		return states
+	}
+
+	last := states()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if current := states(); !reflect.DeepEqual(current, last) {
+					last = current
+					onChange()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// reload reads the config files into a new config struct and, when it is valid and differs
+// from the current config, replaces it and calls the WithWatch callback. It returns the keys
+// that changed. The positional arguments are kept.
+func (c *Cmder) reload(cmd *cobra.Command, command string, args []string) ([]string, error) {
+	w := c.watch
+
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	w.mu.RLock()
+	old := w.current
+	w.mu.RUnlock()
+
+	// The config files are read into a new Viper instance with new sources, swapped under
+	// the lock of Viper and Source, and the previous ones are restored on error.
+	root := c.root()
+	root.mu.Lock()
+
+	prevViper, prevFileSources, prevAliasSources := root.viper, root.fileSources, root.aliasSources
+	root.setViper(root.newViper())
+
+	next, changed, err := c.loadConfig(cmd, command, args, old)
+	if err != nil {
+		root.setViper(prevViper)
+		root.fileSources, root.aliasSources = prevFileSources, prevAliasSources
+	}
+
+	root.mu.Unlock()
+
+	if err != nil || len(changed) == 0 {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	w.current = next
+	w.mu.Unlock()
+
+	if w.onChange != nil {
+		w.onChange(old, next)
+	}
+
+	return changed, nil
+}
+
+// newViper returns an empty Viper instance set up like the one of the options, reading
+// the config file used at startup.
+func (c *Cmder) newViper() *viper.Viper {
+	v := viper.New()
+	v.SetFs(c.fs)
+
+	if c.envPrefix != "" {
+		v.SetEnvPrefix(c.envPrefix)
+	}
+
+	if used := c.viper.ConfigFileUsed(); used != "" {
+		v.SetConfigFile(used)
+	}
+
+	return v
+}
+
+// setViper sets the Viper instance of the Cmder and of its children.
+func (c *Cmder) setViper(v *viper.Viper) {
+	c.viper = v
+
+	for _, child := range c.children {
+		child.setViper(v)
+	}
+}
+
+// bindAll binds the items of the Cmder and of its children to their Viper instance.
+func (c *Cmder) bindAll() error {
+	if err := c.bind(); err != nil {
+		return err
+	}
+
+	for _, child := range c.children {
+		if err := child.bindAll(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadConfig binds the Viper instance of the root, reads the config files and returns the
+// new config struct decoded and validated, and the keys that changed from old.
+func (c *Cmder) loadConfig(cmd *cobra.Command, command string, args []string, old any) (any, []string, error) {
+	root := c.root()
+
+	if err := root.bindAll(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := root.readConfig(cmd); err != nil {
+		return nil, nil, err
+	}
+
+	oldCfg := reflect.ValueOf(old).Elem()
+	next := reflect.New(oldCfg.Type())
+	cfg := next.Elem()
+
+	if err := c.decode(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	for _, item := range c.items {
+		if item.arg == "" {
+			continue
+		}
+
+		if value, ok := item.fieldValue(oldCfg); ok {
+			if field, ok := fieldByIndex(cfg, item.index); ok {
+				setField(field, value)
+			}
+		}
+	}
+
+	if err := c.validate(cfg, command, args); err != nil {
+		return nil, nil, err
+	}
+
+	return next.Interface(), c.changedKeys(oldCfg, cfg, command), nil
+}
+
+// changedKeys returns the keys of the command whose value differs between two config structs.
+func (c *Cmder) changedKeys(old, new reflect.Value, command string) []string {
+	var changed []string
+
+	for _, item := range c.items {
+		if item.arg != "" || !item.inCommand(command) {
+			continue
+		}
+
+		oldValue, oldOk := item.fieldValue(old)
+		newValue, newOk := item.fieldValue(new)
+
+		if oldOk != newOk || oldOk && !reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			changed = append(changed, c.key(item))
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,253 @@
+// Copyright © 2023 Eric Gagnon <github.com/ergagnon>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocmder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+)
+
+type watchTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	fs  afero.Fs
+}
+
+type watchedConfig struct {
+	Level  string `default:"info" enum:"debug,info"`
+	Port   int    `default:"8080"`
+	Server struct {
+		Host string `default:"localhost"`
+	}
+	File string `arg:"0" default:"app.log"`
+}
+
+// syncBuffer is a buffer written by the watcher goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type change struct {
+	old, new *watchedConfig
+}
+
+func (s *watchTestSuite) SetupTest() {
+	s.fs = afero.NewMemMapFs()
+	s.buf.Reset()
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("level: info\n"), 0644))
+}
+
+func (s *watchTestSuite) newCmder(changes *[]change) *Cmder {
+	cmder, err := New(func(context.Context, *watchedConfig) error {
+		return nil
+	}, WithFS(s.fs), WithConfigFile("/config.yaml"), WithWatch(func(old, new *watchedConfig) {
+		*changes = append(*changes, change{old, new})
+	}))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&s.buf)
+	cmder.Cobra().SetArgs([]string{"--port", "9000", "out.log"})
+	s.NoError(cmder.Execute())
+
+	return cmder
+}
+
+func (s *watchTestSuite) TestReload() {
+	var changes []change
+	cmder := s.newCmder(&changes)
+
+	initial := Config[watchedConfig](cmder)
+	s.Equal("info", initial.Level)
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("level: debug\nport: 1\nserver:\n  host: example.com\n"), 0644))
+
+	changed, err := cmder.reload(cmder.Cobra(), "", []string{"out.log"})
+	s.NoError(err)
+	s.Equal([]string{"level", "server.host"}, changed)
+
+	s.Len(changes, 1)
+	s.Same(initial, changes[0].old)
+	s.Same(changes[0].new, Config[watchedConfig](cmder))
+
+	current := Config[watchedConfig](cmder)
+	s.Equal("debug", current.Level)
+	s.Equal(9000, current.Port, "the flags keep their precedence")
+	s.Equal("example.com", current.Server.Host)
+	s.Equal("out.log", current.File)
+	s.Equal("info", initial.Level, "the previous config is not modified")
+}
+
+func (s *watchTestSuite) TestReloadWithoutChange() {
+	var changes []change
+	cmder := s.newCmder(&changes)
+
+	changed, err := cmder.reload(cmder.Cobra(), "", []string{"out.log"})
+	s.NoError(err)
+	s.Empty(changed)
+	s.Empty(changes)
+}
+
+func (s *watchTestSuite) TestReloadInvalid() {
+	var changes []change
+	cmder := s.newCmder(&changes)
+	initial := Config[watchedConfig](cmder)
+
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("level: verbose\n"), 0644))
+
+	_, err := cmder.reload(cmder.Cobra(), "", []string{"out.log"})
+	s.EqualError(err, `level: must be one of debug, info, got "verbose" (file /config.yaml:1)`)
+
+	var fieldErr *FieldError
+	s.True(errors.As(err, &fieldErr))
+
+	s.Empty(changes)
+	s.Same(initial, Config[watchedConfig](cmder))
+}
+
+func (s *watchTestSuite) TestConfigWithoutWatch() {
+	cmder, err := New[watchedConfig](nil)
+	s.NoError(err)
+
+	s.NotNil(Config[watchedConfig](cmder))
+	s.Nil(Config[rootConfig](cmder))
+}
+
+func (s *watchTestSuite) TestWatchConfigTypeMismatch() {
+	_, err := New[watchedConfig](nil, WithWatch(func(_, _ *rootConfig) {}))
+
+	s.EqualError(err, "watch expects config type *gocmder.rootConfig, got *gocmder.watchedConfig")
+}
+
+func (s *watchTestSuite) TestWatchConfigFile() {
+	file := filepath.Join(s.T().TempDir(), "config.yaml")
+	s.NoError(os.WriteFile(file, []byte("level: info\n"), 0644))
+
+	reloaded := make(chan *watchedConfig, 1)
+	var out syncBuffer
+
+	cmder, err := New(func(context.Context, *watchedConfig) error {
+		if err := os.WriteFile(file, []byte("level: debug\n"), 0644); err != nil {
+			return err
+		}
+
+		select {
+		case cfg := <-reloaded:
+			s.Equal("debug", cfg.Level)
+		case <-time.After(5 * time.Second):
+			s.Fail("the config was not reloaded")
+		}
+
+		return nil
+	}, WithConfigFile(file), WithWatch(func(_, new *watchedConfig) {
+		select {
+		case reloaded <- new:
+		default:
+		}
+	}))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&out)
+	cmder.Cobra().SetArgs([]string{})
+	s.NoError(cmder.Execute())
+
+	s.Equal("debug", Config[watchedConfig](cmder).Level)
+	s.Eventually(func() bool {
+		return strings.Contains(out.String(), "config reloaded, changed keys: level\n")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (s *watchTestSuite) TestWatchConfigFiles() {
+	defer func(interval time.Duration) { watchInterval = interval }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	s.NoError(afero.WriteFile(s.fs, "/base.yaml", []byte("level: info\n"), 0644))
+	s.NoError(afero.WriteFile(s.fs, "/config.yaml", []byte("port: 1\n"), 0644))
+
+	reloaded := make(chan *watchedConfig, 1)
+	var out syncBuffer
+	var cmder *Cmder
+
+	cmder, err := New(func(context.Context, *watchedConfig) error {
+		if err := afero.WriteFile(s.fs, "/base.yaml", []byte("server:\n  host: example.com\nlevel: debug\n"), 0644); err != nil {
+			return err
+		}
+
+		// The sources and the Viper instance are read while the watcher reloads them.
+		timeout := time.After(5 * time.Second)
+		for {
+			source, ok := cmder.Source("level")
+			s.True(ok)
+			s.Contains([]string{"file /base.yaml:1", "file /base.yaml:3"}, source.String())
+			s.Contains([]string{"info", "debug"}, cmder.Viper().GetString("level"))
+
+			select {
+			case cfg := <-reloaded:
+				s.Equal("debug", cfg.Level)
+				return nil
+			case <-timeout:
+				s.Fail("the config was not reloaded")
+				return nil
+			default:
+			}
+		}
+	}, WithFS(s.fs), WithConfigFiles("/base.yaml"), WithConfigFile("/config.yaml"), WithWatch(func(_, new *watchedConfig) {
+		select {
+		case reloaded <- new:
+		default:
+		}
+	}))
+	s.NoError(err)
+
+	cmder.Cobra().SetOutput(&out)
+	cmder.Cobra().SetArgs([]string{})
+	s.NoError(cmder.Execute())
+
+	source, ok := cmder.Source("level")
+	s.True(ok)
+	s.Equal("file /base.yaml:3", source.String())
+	s.Equal("example.com", Config[watchedConfig](cmder).Server.Host)
+	s.Equal(1, Config[watchedConfig](cmder).Port)
+	s.Eventually(func() bool {
+		return strings.Contains(out.String(), "config reloaded, changed keys: level, server.host\n")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchTestSuite(t *testing.T) {
+	suite.Run(t, new(watchTestSuite))
+}